@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"sync/atomic"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -38,7 +39,8 @@ type SignerApp struct {
 	metricsServer *httputil.HTTPServer
 	registry      *prometheus.Registry
 
-	signer *service.SignerService
+	signer      *service.SignerService
+	idempotency *service.IdempotencyCache
 
 	rpc *oprpc.Server
 
@@ -91,6 +93,7 @@ func (s *SignerApp) initPprof(cfg *Config) error {
 func (s *SignerApp) initMetrics(cfg *Config) error {
 	registry := opmetrics.NewRegistry()
 	registry.MustRegister(service.MetricSignTransactionTotal)
+	registry.MustRegister(service.MetricIdempotentHitsTotal)
 	s.registry = registry // some things require metrics registry
 
 	if !cfg.MetricsConfig.Enabled {
@@ -142,6 +145,7 @@ func (s *SignerApp) initRPC(cfg *Config) error {
 		oprpc.WithLogger(s.log),
 		oprpc.WithTLSConfig(serverTlsConfig),
 		oprpc.WithMiddleware(service.NewAuthMiddleware()),
+		oprpc.WithMiddleware(service.NewIdempotencyMiddleware()),
 		oprpc.WithHTTPRecorder(opmetrics.NewPromHTTPRecorder(s.registry, "signer")),
 	)
 
@@ -149,7 +153,10 @@ func (s *SignerApp) initRPC(cfg *Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to read service config: %w", err)
 	}
-	s.signer = service.NewSignerService(s.log, serviceCfg)
+	if cfg.IdempotencyCacheSize > 0 {
+		s.idempotency = service.NewIdempotencyCache(cfg.IdempotencyCacheSize, service.DefaultIdempotencyTTL)
+	}
+	s.signer = service.NewSignerService(s.log, serviceCfg, s.idempotency)
 	s.signer.RegisterAPIs(s.rpc)
 
 	if err := s.rpc.Start(); err != nil {
@@ -171,6 +178,9 @@ func (s *SignerApp) Stop(ctx context.Context) error {
 			result = errors.Join(result, fmt.Errorf("failed to stop RPC server: %w", err))
 		}
 	}
+	if s.idempotency != nil {
+		s.idempotency.Close()
+	}
 	if s.pprofServer != nil {
 		if err := s.pprofServer.Stop(ctx); err != nil {
 			result = errors.Join(result, fmt.Errorf("failed to stop pprof server: %w", err))
@@ -203,6 +213,7 @@ type SignActionType string
 const (
 	SignTransaction  SignActionType = "transaction"
 	SignBlockPayload SignActionType = "block_payload"
+	GetSignerAddress SignActionType = "signer_address"
 )
 
 func ClientSign(version string, action SignActionType) func(cliCtx *cli.Context) error {
@@ -267,6 +278,28 @@ func ClientSign(version string, action SignActionType) func(cliCtx *cli.Context)
 
 			fmt.Println(string(signature[:]))
 
+		case GetSignerAddress:
+			chainIDArg := cliCtx.Args().Get(0)
+			if chainIDArg == "" {
+				return errors.New("no chain id argument was provided")
+			}
+			chainID, err := strconv.ParseUint(chainIDArg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse chain id argument: %w", err)
+			}
+
+			client, err := client.NewSignerClient(l, cfg.ClientEndpoint, cfg.TLSConfig)
+			if err != nil {
+				return err
+			}
+
+			address, err := client.GetSignerAddress(context.Background(), chainID)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(address.Hex())
+
 		case "":
 			return errors.New("no action was provided")
 		}
@@ -18,6 +18,15 @@ import (
 	optls "github.com/ethereum-optimism/optimism/op-service/tls"
 )
 
+// IdempotencyKeyHeader must match the header read by the signer service
+const IdempotencyKeyHeader = "X-Idempotency-Key"
+
+// WithIdempotencyKey attaches an idempotency key (typically a UUID) to signing requests made
+// with the returned context. Retrying a request with the same key returns the original result.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return rpc.NewContextWithHeaders(ctx, http.Header{IdempotencyKeyHeader: []string{key}})
+}
+
 type SignerClient struct {
 	client *rpc.Client
 	status string
@@ -79,7 +88,7 @@ func (s *SignerClient) SignTransaction(
 
 	var result hexutil.Bytes
 
-	if err := s.client.Call(&result, "eth_signTransaction", args); err != nil {
+	if err := s.client.CallContext(ctx, &result, "eth_signTransaction", args); err != nil {
 		return nil, fmt.Errorf("eth_signTransaction failed: %w", err)
 	}
 
@@ -91,13 +100,24 @@ func (s *SignerClient) SignTransaction(
 	return signed, nil
 }
 
+// GetSignerAddress returns the address the signer signs with for the given chain
+func (s *SignerClient) GetSignerAddress(ctx context.Context, chainID uint64) (common.Address, error) {
+	var result common.Address
+
+	if err := s.client.CallContext(ctx, &result, "opsigner_getSignerAddress", hexutil.Uint64(chainID)); err != nil {
+		return common.Address{}, fmt.Errorf("opsigner_getSignerAddress failed: %w", err)
+	}
+
+	return result, nil
+}
+
 func (s *SignerClient) SignBlockPayload(
 	ctx context.Context,
 	signingHash common.Hash,
 ) ([]byte, error) {
 	var result []byte
 
-	if err := s.client.Call(&result, "eth_signBlockPayload", signingHash); err != nil {
+	if err := s.client.CallContext(ctx, &result, "eth_signBlockPayload", signingHash); err != nil {
 		return []byte{}, fmt.Errorf("eth_signBlockPayload failed: %w", err)
 	}
 
@@ -45,6 +45,12 @@ func main() {
 					Action: signer.ClientSign(Version, signer.SignBlockPayload),
 					Flags:  cliapp.ProtectFlags(signer.ClientSignCLIFlags("SIGNER")),
 				},
+				{
+					Name:   string(signer.GetSignerAddress),
+					Usage:  "get the signer address for a chain id",
+					Action: signer.ClientSign(Version, signer.GetSignerAddress),
+					Flags:  cliapp.ProtectFlags(signer.ClientSignCLIFlags("SIGNER")),
+				},
 			},
 		},
 	}
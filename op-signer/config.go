@@ -1,6 +1,8 @@
 package app
 
 import (
+	"errors"
+
 	"github.com/urfave/cli/v2"
 
 	opservice "github.com/ethereum-optimism/optimism/op-service"
@@ -9,11 +11,14 @@ import (
 	"github.com/ethereum-optimism/optimism/op-service/oppprof"
 	oprpc "github.com/ethereum-optimism/optimism/op-service/rpc"
 	optls "github.com/ethereum-optimism/optimism/op-service/tls"
+
+	"github.com/ethereum-optimism/infra/op-signer/service"
 )
 
 const (
-	ServiceConfigPathFlagName = "config"
-	ClientEndpointFlagName    = "endpoint"
+	ServiceConfigPathFlagName    = "config"
+	ClientEndpointFlagName       = "endpoint"
+	IdempotencyCacheSizeFlagName = "idempotency-cache-size"
 )
 
 func CLIFlags(envPrefix string) []cli.Flag {
@@ -24,6 +29,12 @@ func CLIFlags(envPrefix string) []cli.Flag {
 			Value:   "config.yaml",
 			EnvVars: opservice.PrefixEnvVar(envPrefix, "SERVICE_CONFIG"),
 		},
+		&cli.IntFlag{
+			Name:    IdempotencyCacheSizeFlagName,
+			Usage:   "Maximum number of signing results cached by X-Idempotency-Key. Set to 0 to disable",
+			Value:   service.DefaultIdempotencyCacheSize,
+			EnvVars: opservice.PrefixEnvVar(envPrefix, "IDEMPOTENCY_CACHE_SIZE"),
+		},
 	}
 	flags = append(flags, oprpc.CLIFlags(envPrefix)...)
 	flags = append(flags, oplog.CLIFlags(envPrefix)...)
@@ -46,8 +57,9 @@ func ClientSignCLIFlags(envPrefix string) []cli.Flag {
 }
 
 type Config struct {
-	ClientEndpoint    string
-	ServiceConfigPath string
+	ClientEndpoint       string
+	ServiceConfigPath    string
+	IdempotencyCacheSize int
 
 	TLSConfig     optls.CLIConfig
 	RPCConfig     oprpc.CLIConfig
@@ -69,17 +81,21 @@ func (c Config) Check() error {
 	if err := c.TLSConfig.Check(); err != nil {
 		return err
 	}
+	if c.IdempotencyCacheSize < 0 {
+		return errors.New("idempotency cache size must not be negative")
+	}
 	return nil
 }
 
 func NewConfig(ctx *cli.Context) *Config {
 	return &Config{
-		ClientEndpoint:    ctx.String(ClientEndpointFlagName),
-		ServiceConfigPath: ctx.String(ServiceConfigPathFlagName),
-		TLSConfig:         optls.ReadCLIConfig(ctx),
-		RPCConfig:         oprpc.ReadCLIConfig(ctx),
-		LogConfig:         oplog.ReadCLIConfig(ctx),
-		MetricsConfig:     opmetrics.ReadCLIConfig(ctx),
-		PprofConfig:       oppprof.ReadCLIConfig(ctx),
+		ClientEndpoint:       ctx.String(ClientEndpointFlagName),
+		ServiceConfigPath:    ctx.String(ServiceConfigPathFlagName),
+		IdempotencyCacheSize: ctx.Int(IdempotencyCacheSizeFlagName),
+		TLSConfig:            optls.ReadCLIConfig(ctx),
+		RPCConfig:            oprpc.ReadCLIConfig(ctx),
+		LogConfig:            oplog.ReadCLIConfig(ctx),
+		MetricsConfig:        opmetrics.ReadCLIConfig(ctx),
+		PprofConfig:          oppprof.ReadCLIConfig(ctx),
 	}
 }
@@ -72,3 +72,15 @@ func (s SignerServiceConfig) GetAuthConfigForClient(clientName string, fromAddre
 	}
 	return nil, fmt.Errorf("client '%s' is not authorized to use any keys", clientName)
 }
+
+func (s SignerServiceConfig) GetAuthConfigForClientAndChain(clientName string, chainID uint64) (*AuthConfig, error) {
+	if clientName == "" {
+		return nil, errors.New("client name is empty")
+	}
+	for _, ac := range s.Auth {
+		if ac.ClientName == clientName && ac.ChainID == chainID {
+			return &ac, nil
+		}
+	}
+	return nil, fmt.Errorf("client '%s' is not authorized to use any keys for chain %d", clientName, chainID)
+}
@@ -19,3 +19,13 @@ type UnauthorizedBlockPayloadError struct{ message string }
 
 func (e *UnauthorizedBlockPayloadError) Error() string  { return e.message }
 func (e *UnauthorizedBlockPayloadError) ErrorCode() int { return -32013 }
+
+type IdempotencyKeyConflictError struct{ message string }
+
+func (e *IdempotencyKeyConflictError) Error() string  { return e.message }
+func (e *IdempotencyKeyConflictError) ErrorCode() int { return -32014 }
+
+type PublicKeyError struct{ message string }
+
+func (e *PublicKeyError) Error() string  { return e.message }
+func (e *PublicKeyError) ErrorCode() int { return -32015 }
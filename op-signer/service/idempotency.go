@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
+
+	oprpc "github.com/ethereum-optimism/optimism/op-service/rpc"
+)
+
+const (
+	// IdempotencyKeyHeader is set by clients (typically to a UUID) so that a retried
+	// signing request returns the original result instead of signing again.
+	IdempotencyKeyHeader = "X-Idempotency-Key"
+
+	DefaultIdempotencyCacheSize = 10000
+	DefaultIdempotencyTTL       = 5 * time.Minute
+
+	idempotencySweepInterval = 30 * time.Second
+)
+
+type idempotencyKeyContextKey struct{}
+
+// SignatureResult is the cached result of a signing operation
+type SignatureResult struct {
+	// Signature is the exact response returned to the client (signed tx or signature)
+	Signature hexutil.Bytes
+	// SignedAt is the time of the original signing
+	SignedAt time.Time
+
+	// payloadHash identifies the signed payload so a key cannot be replayed for a different one
+	payloadHash common.Hash
+}
+
+// IdempotencyCache remembers signing results by idempotency key for a limited time.
+// When full, the least recently used entry is evicted.
+type IdempotencyCache struct {
+	entries *lru.Cache[string, SignatureResult]
+	ttl     time.Duration
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+func NewIdempotencyCache(size int, ttl time.Duration) *IdempotencyCache {
+	c := &IdempotencyCache{
+		entries: lru.NewCache[string, SignatureResult](size),
+		ttl:     ttl,
+		closeCh: make(chan struct{}),
+	}
+	go c.sweepLoop()
+	return c
+}
+
+// Close stops the background sweeper
+func (c *IdempotencyCache) Close() {
+	c.closeOnce.Do(func() { close(c.closeCh) })
+}
+
+func (c *IdempotencyCache) sweepLoop() {
+	ticker := time.NewTicker(idempotencySweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.sweep(time.Now())
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
+// sweep removes all entries older than the ttl
+func (c *IdempotencyCache) sweep(now time.Time) {
+	for _, key := range c.entries.Keys() {
+		if res, ok := c.entries.Peek(key); ok && now.Sub(res.SignedAt) > c.ttl {
+			c.entries.Remove(key)
+		}
+	}
+}
+
+// Lookup returns the cached result for the idempotency key attached to ctx, if any.
+// An error is returned when the key was previously used to sign a different payload.
+// A nil cache, or a request without an idempotency key, never hits.
+func (c *IdempotencyCache) Lookup(ctx context.Context, operation string, clientName string, payloadHash common.Hash) (SignatureResult, bool, error) {
+	key, ok := idempotencyCacheKey(ctx, operation, clientName)
+	if c == nil || !ok {
+		return SignatureResult{}, false, nil
+	}
+
+	res, ok := c.entries.Get(key)
+	if !ok || time.Since(res.SignedAt) > c.ttl {
+		return SignatureResult{}, false, nil
+	}
+	if res.payloadHash != payloadHash {
+		return SignatureResult{}, false, &IdempotencyKeyConflictError{"idempotency key reused for a different payload"}
+	}
+
+	MetricIdempotentHitsTotal.WithLabelValues(operation).Inc()
+	return res, true, nil
+}
+
+// Store caches the signing result under the idempotency key attached to ctx, if any
+func (c *IdempotencyCache) Store(ctx context.Context, operation string, clientName string, payloadHash common.Hash, signature hexutil.Bytes) {
+	key, ok := idempotencyCacheKey(ctx, operation, clientName)
+	if c == nil || !ok {
+		return
+	}
+	c.entries.Add(key, SignatureResult{Signature: signature, SignedAt: time.Now(), payloadHash: payloadHash})
+}
+
+// idempotencyCacheKey scopes the client supplied key by client and operation
+func idempotencyCacheKey(ctx context.Context, operation string, clientName string) (string, bool) {
+	key := IdempotencyKeyFromContext(ctx)
+	if key == "" {
+		return "", false
+	}
+	return clientName + "/" + operation + "/" + key, true
+}
+
+// NewIdempotencyMiddleware attaches the idempotency key request header, if present, to the request context
+func NewIdempotencyMiddleware() oprpc.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := context.WithValue(r.Context(), idempotencyKeyContextKey{}, key)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
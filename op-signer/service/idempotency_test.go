@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/ethereum-optimism/infra/op-signer/service/provider"
+	clientSigner "github.com/ethereum-optimism/optimism/op-service/signer"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set(IdempotencyKeyHeader, "4d3c1f9a-8f53-4cbb-9b3e-0b1f1f0b7e2a")
+
+	handlerInvoked := false
+	handler := NewIdempotencyMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "4d3c1f9a-8f53-4cbb-9b3e-0b1f1f0b7e2a", IdempotencyKeyFromContext(r.Context()))
+		handlerInvoked = true
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.True(t, handlerInvoked)
+}
+
+func TestIdempotencyCache_Sweep(t *testing.T) {
+	cache := NewIdempotencyCache(10, time.Minute)
+	defer cache.Close()
+
+	ctx := context.WithValue(context.Background(), idempotencyKeyContextKey{}, "key")
+	cache.Store(ctx, operationBlockPayload, "client.oplabs.co", common.Hash{}, hexutil.Bytes{0x01})
+
+	cache.sweep(time.Now())
+	_, ok, err := cache.Lookup(ctx, operationBlockPayload, "client.oplabs.co", common.Hash{})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	cache.sweep(time.Now().Add(2 * time.Minute))
+	require.Zero(t, cache.entries.Len())
+}
+
+func TestIdempotencyCache_LRUEviction(t *testing.T) {
+	cache := NewIdempotencyCache(1, time.Minute)
+	defer cache.Close()
+
+	ctxA := context.WithValue(context.Background(), idempotencyKeyContextKey{}, "a")
+	ctxB := context.WithValue(context.Background(), idempotencyKeyContextKey{}, "b")
+	cache.Store(ctxA, operationBlockPayload, "client.oplabs.co", common.Hash{}, hexutil.Bytes{0x01})
+	cache.Store(ctxB, operationBlockPayload, "client.oplabs.co", common.Hash{}, hexutil.Bytes{0x02})
+
+	_, ok, _ := cache.Lookup(ctxA, operationBlockPayload, "client.oplabs.co", common.Hash{})
+	require.False(t, ok)
+	_, ok, _ = cache.Lookup(ctxB, operationBlockPayload, "client.oplabs.co", common.Hash{})
+	require.True(t, ok)
+}
+
+// TestIdempotentSignBlockPayload exercises the full rpc path: a retried request with the
+// same idempotency key must return a byte-identical response without signing again.
+func TestIdempotentSignBlockPayload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	priv, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(priv.PublicKey)
+
+	cfg := SignerServiceConfig{
+		Auth: []AuthConfig{{ClientName: "client.oplabs.co", KeyName: "keyName", ChainID: 1, FromAddress: sender}},
+	}
+
+	cache := NewIdempotencyCache(DefaultIdempotencyCacheSize, DefaultIdempotencyTTL)
+	defer cache.Close()
+
+	mockSignatureProvider := provider.NewMockSignatureProvider(ctrl)
+	signerService := NewSignerServiceWithProvider(log.Root(), cfg, mockSignatureProvider, cache)
+
+	srv := rpc.NewServer()
+	defer srv.Stop()
+	require.NoError(t, srv.RegisterName("opsigner", signerService.opsigner))
+
+	// stand-in for the mTLS auth middleware
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientInfoContextKey{}, ClientInfo{ClientName: "client.oplabs.co"})
+		srv.ServeHTTP(w, r.WithContext(ctx))
+	})
+	httpSrv := httptest.NewServer(NewIdempotencyMiddleware()(handler))
+	defer httpSrv.Close()
+
+	client, err := rpc.Dial(httpSrv.URL)
+	require.NoError(t, err)
+	defer client.Close()
+
+	args := clientSigner.NewBlockPayloadArgs([32]byte{}, big.NewInt(1), []byte("c0ffee"), &sender)
+	signingHash, err := args.ToSigningHash()
+	require.NoError(t, err)
+	signature, err := crypto.Sign(signingHash.Bytes(), priv)
+	require.NoError(t, err)
+
+	mockSignatureProvider.EXPECT().
+		SignDigest(gomock.Any(), "keyName", signingHash.Bytes()).
+		Return(signature, nil).
+		Times(1)
+
+	ctx := rpc.NewContextWithHeaders(context.Background(), http.Header{IdempotencyKeyHeader: []string{"4d3c1f9a-8f53-4cbb-9b3e-0b1f1f0b7e2a"}})
+
+	var first, second hexutil.Bytes
+	require.NoError(t, client.CallContext(ctx, &first, "opsigner_signBlockPayload", args))
+	require.NoError(t, client.CallContext(ctx, &second, "opsigner_signBlockPayload", args))
+	require.Equal(t, []byte(first), []byte(second))
+	require.Equal(t, hexutil.Encode(signature), first.String())
+
+	// reusing the key for a different payload is rejected
+	other := clientSigner.NewBlockPayloadArgs([32]byte{}, big.NewInt(1), []byte("decaf"), &sender)
+	var third hexutil.Bytes
+	err = client.CallContext(ctx, &third, "opsigner_signBlockPayload", other)
+	var rpcErr rpc.Error
+	require.True(t, errors.As(err, &rpcErr))
+	require.Equal(t, -32014, rpcErr.ErrorCode())
+}
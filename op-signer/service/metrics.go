@@ -9,4 +9,10 @@ var (
 			Help: ""},
 		[]string{"client", "status", "error"},
 	)
+	MetricIdempotentHitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "op_signer_idempotent_hits_total",
+			Help: "Number of signing requests served from the idempotency cache"},
+		[]string{"operation"},
+	)
 )
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/prometheus/client_golang/prometheus"
@@ -22,28 +24,37 @@ type SignerService struct {
 }
 
 type EthService struct {
-	logger   log.Logger
-	config   SignerServiceConfig
-	provider provider.SignatureProvider
+	logger      log.Logger
+	config      SignerServiceConfig
+	provider    provider.SignatureProvider
+	idempotency *IdempotencyCache
 }
 
 type OpsignerSerivce struct {
-	logger   log.Logger
-	config   SignerServiceConfig
-	provider provider.SignatureProvider
+	logger      log.Logger
+	config      SignerServiceConfig
+	provider    provider.SignatureProvider
+	idempotency *IdempotencyCache
 }
 
-func NewSignerService(logger log.Logger, config SignerServiceConfig) *SignerService {
-	return NewSignerServiceWithProvider(logger, config, provider.NewCloudKMSSignatureProvider(logger))
+const (
+	operationTransaction  = "transaction"
+	operationBlockPayload = "block_payload"
+)
+
+// NewSignerService creates a signer backed by Cloud KMS. The idempotency cache is optional.
+func NewSignerService(logger log.Logger, config SignerServiceConfig, idempotency *IdempotencyCache) *SignerService {
+	return NewSignerServiceWithProvider(logger, config, provider.NewCloudKMSSignatureProvider(logger), idempotency)
 }
 
 func NewSignerServiceWithProvider(
 	logger log.Logger,
 	config SignerServiceConfig,
 	provider provider.SignatureProvider,
+	idempotency *IdempotencyCache,
 ) *SignerService {
-	ethService := EthService{logger, config, provider}
-	opsignerService := OpsignerSerivce{logger, config, provider}
+	ethService := EthService{logger, config, provider, idempotency}
+	opsignerService := OpsignerSerivce{logger, config, provider, idempotency}
 	return &SignerService{&ethService, &opsignerService}
 }
 
@@ -103,6 +114,18 @@ func (s *EthService) SignTransaction(ctx context.Context, args signer.Transactio
 	txSigner := types.LatestSignerForChainID(tx.ChainId())
 	digest := txSigner.Hash(tx)
 
+	cached, ok, err := s.idempotency.Lookup(ctx, operationTransaction, clientInfo.ClientName, digest)
+	if err != nil {
+		labels["error"] = "idempotency_conflict"
+		return nil, err
+	}
+	if ok {
+		s.logger.Info("Returning cached signed transaction", "digest", hexutil.Encode(digest.Bytes()),
+			"client.name", clientInfo.ClientName, "signedAt", cached.SignedAt)
+		labels["status"] = "success"
+		return cached.Signature, nil
+	}
+
 	signature, err := s.provider.SignDigest(ctx, authConfig.KeyName, digest.Bytes())
 	if err != nil {
 		labels["error"] = "sign_error"
@@ -136,6 +159,8 @@ func (s *EthService) SignTransaction(ctx context.Context, args signer.Transactio
 	}
 
 	labels["status"] = "success"
+	s.idempotency.Store(ctx, operationTransaction, clientInfo.ClientName, digest, txraw)
+
 	txTo := ""
 	if tx.To() != nil {
 		txTo = tx.To().Hex()
@@ -204,6 +229,18 @@ func (s *OpsignerSerivce) SignBlockPayload(ctx context.Context, args signer.Bloc
 		return nil, &InvalidBlockPayloadError{err.Error()}
 	}
 
+	cached, ok, err := s.idempotency.Lookup(ctx, operationBlockPayload, clientInfo.ClientName, signingHash)
+	if err != nil {
+		labels["error"] = "idempotency_conflict"
+		return nil, err
+	}
+	if ok {
+		s.logger.Info("Returning cached block payload signature", "signingHash", hexutil.Encode(signingHash.Bytes()),
+			"client.name", clientInfo.ClientName, "signedAt", cached.SignedAt)
+		labels["status"] = "success"
+		return cached.Signature, nil
+	}
+
 	signature, err := s.provider.SignDigest(ctx, authConfig.KeyName, signingHash[:])
 	if err != nil {
 		labels["error"] = "sign_error"
@@ -211,6 +248,7 @@ func (s *OpsignerSerivce) SignBlockPayload(ctx context.Context, args signer.Bloc
 	}
 
 	labels["status"] = "success"
+	s.idempotency.Store(ctx, operationBlockPayload, clientInfo.ClientName, signingHash, signature)
 
 	s.logger.Info(
 		"Signed block payload",
@@ -220,3 +258,24 @@ func (s *OpsignerSerivce) SignBlockPayload(ctx context.Context, args signer.Bloc
 
 	return signature, nil
 }
+
+// GetSignerAddress returns the address of the key the authenticated client signs with for the given chain
+func (s *OpsignerSerivce) GetSignerAddress(ctx context.Context, chainID hexutil.Uint64) (common.Address, error) {
+	clientInfo := ClientInfoFromContext(ctx)
+	authConfig, err := s.config.GetAuthConfigForClientAndChain(clientInfo.ClientName, uint64(chainID))
+	if err != nil {
+		return common.Address{}, rpc.HTTPError{StatusCode: 403, Status: "Forbidden", Body: []byte(err.Error())}
+	}
+
+	pubKeyBytes, err := s.provider.GetPublicKey(ctx, authConfig.KeyName)
+	if err != nil {
+		s.logger.Warn("failed to get public key", "client.name", clientInfo.ClientName, "err", err)
+		return common.Address{}, &PublicKeyError{err.Error()}
+	}
+	pubKey, err := crypto.UnmarshalPubkey(pubKeyBytes)
+	if err != nil {
+		return common.Address{}, &PublicKeyError{err.Error()}
+	}
+
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
@@ -150,7 +150,7 @@ func testSignTransaction(t *testing.T, tx *types.Transaction) {
 	for _, tt := range tests {
 		t.Run(tt.testName, func(t *testing.T) {
 			mockSignatureProvider := provider.NewMockSignatureProvider(ctrl)
-			service := NewSignerServiceWithProvider(log.Root(), config, mockSignatureProvider)
+			service := NewSignerServiceWithProvider(log.Root(), config, mockSignatureProvider, nil)
 
 			ctx := context.WithValue(context.TODO(), clientInfoContextKey{}, ClientInfo{ClientName: tt.clientName})
 			if tt.wantErrCode == 0 || tt.testName == "invalid from" {
@@ -237,7 +237,7 @@ func TestSignBlockPayload(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.testName, func(t *testing.T) {
 			mockSignatureProvider := provider.NewMockSignatureProvider(ctrl)
-			service := NewSignerServiceWithProvider(log.Root(), blockPayloadConfig, mockSignatureProvider)
+			service := NewSignerServiceWithProvider(log.Root(), blockPayloadConfig, mockSignatureProvider, nil)
 
 			ctx := context.WithValue(context.TODO(), clientInfoContextKey{}, ClientInfo{ClientName: tt.clientName})
 			if tt.wantErrCode == 0 || tt.testName == "invalid from" {
@@ -268,3 +268,65 @@ func TestSignBlockPayload(t *testing.T) {
 		})
 	}
 }
+
+func TestGetSignerAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	priv, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	pubKey := crypto.FromECDSAPub(&priv.PublicKey)
+	address := crypto.PubkeyToAddress(priv.PublicKey)
+
+	addressConfig := SignerServiceConfig{
+		Auth: []AuthConfig{
+			{ClientName: "client.oplabs.co", KeyName: "keyName", ChainID: 10},
+			{ClientName: "client.oplabs.co", KeyName: "otherKeyName", ChainID: 8453},
+		},
+	}
+
+	tests := []struct {
+		testName    string
+		clientName  string
+		chainID     uint64
+		keyName     string
+		providerErr error
+		wantErrCode int
+	}{
+		{"happy path", "client.oplabs.co", 10, "keyName", nil, 0},
+		{"other chain", "client.oplabs.co", 8453, "otherKeyName", nil, 0},
+		{"chain not authorized", "client.oplabs.co", 1, "", nil, 403},
+		{"client not authorized", "forbidden-client.oplabs.co", 10, "", nil, 403},
+		{"client empty", "", 10, "", nil, 403},
+		{"provider error", "client.oplabs.co", 10, "keyName", errors.New("kms unavailable"), -32015},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			mockSignatureProvider := provider.NewMockSignatureProvider(ctrl)
+			service := NewSignerServiceWithProvider(log.Root(), addressConfig, mockSignatureProvider, nil)
+
+			ctx := context.WithValue(context.TODO(), clientInfoContextKey{}, ClientInfo{ClientName: tt.clientName})
+			if tt.keyName != "" {
+				mockSignatureProvider.EXPECT().
+					GetPublicKey(ctx, tt.keyName).
+					Return(pubKey, tt.providerErr)
+			}
+			resp, err := service.opsigner.GetSignerAddress(ctx, hexutil.Uint64(tt.chainID))
+			if tt.wantErrCode == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, address, resp)
+			} else {
+				assert.Error(t, err)
+				var rpcErr rpc.Error
+				var httpErr rpc.HTTPError
+				if errors.As(err, &rpcErr) {
+					assert.Equal(t, tt.wantErrCode, rpcErr.ErrorCode())
+				} else if errors.As(err, &httpErr) {
+					assert.Equal(t, tt.wantErrCode, httpErr.StatusCode)
+				} else {
+					assert.Fail(t, "returned error is not an rpc.Error or rpc.HTTPError")
+				}
+			}
+		})
+	}
+}
@@ -29,6 +29,22 @@ var (
 	maxCostExceededErr   = &rpc.JsonError{Message: "max cost exceeded", Code: params.TransactionConditionalRejectedErrCode}
 )
 
+const (
+	// outcomes
+	outcomeForwarded         = "forwarded"
+	outcomeRejectedFilter    = "rejected-filter"
+	outcomeRejectedMalformed = "rejected-malformed"
+
+	// rejection reasons. Kept to a fixed set to bound label cardinality
+	reasonNone       = ""
+	reasonDisabled   = "disabled"
+	reasonMalformed  = "malformed_tx"
+	reasonEntrypoint = "unsupported_target"
+	reasonValidation = "failed_validation"
+	reasonMaxCost    = "max_cost_exceeded"
+	reasonRateLimit  = "rate_limited"
+)
+
 type ConditionalTxService struct {
 	log log.Logger
 	cfg *CLIConfig
@@ -40,6 +56,7 @@ type ConditionalTxService struct {
 	costSummary prometheus.Summary
 	requests    prometheus.Counter
 	failures    *prometheus.CounterVec
+	outcomes    *prometheus.CounterVec
 }
 
 func NewConditionalTxService(ctx context.Context, log log.Logger, m metrics.Factory, cfg *CLIConfig) (*ConditionalTxService, error) {
@@ -77,6 +94,11 @@ func NewConditionalTxService(ctx context.Context, log log.Logger, m metrics.Fact
 			Name:      "txconditional_failures",
 			Help:      "number of conditional transaction failures",
 		}, []string{"err"}),
+		outcomes: m.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNameSpace,
+			Name:      "txconditional_outcomes",
+			Help:      "number of conditional transactions by outcome (forwarded, rejected-filter, rejected-malformed) and rejection reason",
+		}, []string{"outcome", "reason"}),
 	}, nil
 }
 
@@ -84,6 +106,7 @@ func (s *ConditionalTxService) SendRawTransactionConditional(ctx context.Context
 	s.requests.Inc()
 	if !s.cfg.SendRawTransactionConditionalEnabled {
 		s.failures.WithLabelValues("disabled").Inc()
+		s.outcomes.WithLabelValues(outcomeRejectedFilter, reasonDisabled).Inc()
 		return common.Hash{}, endpointDisabledErr
 	}
 
@@ -99,6 +122,7 @@ func (s *ConditionalTxService) SendRawTransactionConditional(ctx context.Context
 func (s *ConditionalTxService) sendCondTx(ctx context.Context, txBytes hexutil.Bytes, cond *types.TransactionConditional) (common.Hash, error) {
 	tx := new(types.Transaction)
 	if err := tx.UnmarshalBinary(txBytes); err != nil {
+		s.outcomes.WithLabelValues(outcomeRejectedMalformed, reasonMalformed).Inc()
 		return common.Hash{}, fmt.Errorf("failed to unmarshal tx: %w", err)
 	}
 
@@ -106,23 +130,28 @@ func (s *ConditionalTxService) sendCondTx(ctx context.Context, txBytes hexutil.B
 
 	// external checks (tx target, conditional cost & validation)
 	if tx.To() == nil || !s.entrypointAddresses[*tx.To()] {
+		s.outcomes.WithLabelValues(outcomeRejectedFilter, reasonEntrypoint).Inc()
 		return txHash, entrypointSupportErr
 	}
 	if err := cond.Validate(); err != nil {
 		s.log.Info("failed conditional validation", "err", err)
+		s.outcomes.WithLabelValues(outcomeRejectedMalformed, reasonValidation).Inc()
 		return txHash, failedValidationErr
 	}
 	if cost > params.TransactionConditionalMaxCost {
 		s.log.Info("conditional max cost exceeded", "cost", cost, "max", params.TransactionConditionalMaxCost)
+		s.outcomes.WithLabelValues(outcomeRejectedFilter, reasonMaxCost).Inc()
 		return txHash, maxCostExceededErr
 	}
 
 	// enforce rate limit on the cost to be observed
 	if err := s.limiter.WaitN(ctx, cost); err != nil {
+		s.outcomes.WithLabelValues(outcomeRejectedFilter, reasonRateLimit).Inc()
 		return txHash, rateLimitErr
 	}
 
 	s.costSummary.Observe(float64(cost))
+	s.outcomes.WithLabelValues(outcomeForwarded, reasonNone).Inc()
 	s.log.Info("broadcasting conditional transaction", "hash", txHash.String())
 	return txHash, s.backend.CallContext(ctx, nil, "eth_sendRawTransactionConditional", txBytes, cond)
 }
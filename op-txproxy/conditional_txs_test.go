@@ -18,6 +18,8 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -120,3 +122,32 @@ func TestSendRawTransactionConditionals(t *testing.T) {
 		}
 	}
 }
+
+func TestSendRawTransactionConditionalOutcomes(t *testing.T) {
+	svc := setupSvc(t)
+
+	// malformed
+	_, err := svc.SendRawTransactionConditional(context.Background(), hexutil.Bytes{0x01}, types.TransactionConditional{})
+	require.Error(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.outcomes.WithLabelValues(outcomeRejectedMalformed, reasonMalformed)))
+
+	// filtered by target
+	txBytes, err := rlp.EncodeToBytes(types.NewTransaction(0, common.Address{19: 1}, big.NewInt(0), 0, big.NewInt(0), nil))
+	require.NoError(t, err)
+	_, err = svc.SendRawTransactionConditional(context.Background(), txBytes, types.TransactionConditional{})
+	require.Equal(t, entrypointSupportErr, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.outcomes.WithLabelValues(outcomeRejectedFilter, reasonEntrypoint)))
+
+	// forwarded
+	txBytes, err = rlp.EncodeToBytes(types.NewTransaction(0, predeploys.EntryPoint_v060Addr, big.NewInt(0), 0, big.NewInt(0), nil))
+	require.NoError(t, err)
+	_, err = svc.SendRawTransactionConditional(context.Background(), txBytes, types.TransactionConditional{})
+	require.NoError(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.outcomes.WithLabelValues(outcomeForwarded, reasonNone)))
+
+	// disabled
+	svc.cfg.SendRawTransactionConditionalEnabled = false
+	_, err = svc.SendRawTransactionConditional(context.Background(), txBytes, types.TransactionConditional{})
+	require.Equal(t, endpointDisabledErr, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.outcomes.WithLabelValues(outcomeRejectedFilter, reasonDisabled)))
+}
@@ -0,0 +1,236 @@
+package proxyd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const ContextKeyAccessLog = "access_log"
+
+type AccessLogFormat string
+
+const (
+	// AccessLogFormatJSON writes one JSON object per request
+	AccessLogFormatJSON AccessLogFormat = "json"
+	// AccessLogFormatApache writes lines in the Apache combined log format, followed by the
+	// methods, served by backend, latency in seconds and request id
+	AccessLogFormatApache AccessLogFormat = "apache"
+
+	accessLogRedacted   = "[redacted]"
+	apacheAccessLogTime = "02/Jan/2006:15:04:05 -0700"
+)
+
+// AccessLogger writes a line for every HTTP RPC request, independently of the request logs.
+// Authorization secrets in the request path are redacted.
+type AccessLogger struct {
+	format AccessLogFormat
+	mtx    sync.Mutex
+	out    io.Writer
+	closer io.Closer
+}
+
+func NewAccessLogger(format AccessLogFormat, out io.Writer) (*AccessLogger, error) {
+	switch format {
+	case "":
+		format = AccessLogFormatJSON
+	case AccessLogFormatJSON, AccessLogFormatApache:
+	default:
+		return nil, fmt.Errorf("invalid access log format %q, must be json or apache", format)
+	}
+	return &AccessLogger{
+		format: format,
+		out:    out,
+	}, nil
+}
+
+// NewAccessLoggerFromConfig creates an access logger writing to stdout, stderr or the file
+// at the configured output, which is appended to
+func NewAccessLoggerFromConfig(config AccessLogConfig) (*AccessLogger, error) {
+	var out io.Writer
+	var closer io.Closer
+	switch config.Output {
+	case "", "stdout":
+		out = os.Stdout
+	case "stderr":
+		out = os.Stderr
+	default:
+		f, err := os.OpenFile(config.Output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("error opening access log: %w", err)
+		}
+		out = f
+		closer = f
+	}
+	l, err := NewAccessLogger(config.Format, out)
+	if err != nil {
+		if closer != nil {
+			_ = closer.Close()
+		}
+		return nil, err
+	}
+	l.closer = closer
+	return l, nil
+}
+
+func (l *AccessLogger) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// accessLogEntry collects the fields of a request's access log line while it's served
+type accessLogEntry struct {
+	start      time.Time
+	remoteIP   string
+	auth       string
+	reqID      string
+	httpMethod string
+	path       string
+	proto      string
+	userAgent  string
+	methods    []string
+	servedBy   string
+}
+
+// begin starts the entry of a request, wrapping the response writer to capture the status
+// and size of the response
+func (l *AccessLogger) begin(w http.ResponseWriter, r *http.Request) (*accessLogResponseWriter, *http.Request, *accessLogEntry) {
+	path := r.URL.Path
+	if mux.Vars(r)["authorization"] != "" {
+		path = "/" + accessLogRedacted
+	}
+	entry := &accessLogEntry{
+		start:      time.Now(),
+		httpMethod: r.Method,
+		path:       path,
+		proto:      r.Proto,
+		userAgent:  r.Header.Get("User-Agent"),
+	}
+	rw := &accessLogResponseWriter{ResponseWriter: w}
+	return rw, r.WithContext(context.WithValue(r.Context(), ContextKeyAccessLog, entry)), entry // nolint:staticcheck
+}
+
+// end writes the entry of a finished request
+func (l *AccessLogger) end(w *accessLogResponseWriter, entry *accessLogEntry) {
+	latency := time.Since(entry.start)
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	var buf bytes.Buffer
+	switch l.format {
+	case AccessLogFormatApache:
+		fmt.Fprintf(&buf, "%s - %s [%s] %s %d %d \"-\" %s %s %s %.6f %s\n",
+			orDash(entry.remoteIP),
+			orDash(entry.auth),
+			entry.start.Format(apacheAccessLogTime),
+			strconv.Quote(entry.httpMethod+" "+entry.path+" "+entry.proto),
+			status,
+			w.size,
+			strconv.Quote(entry.userAgent),
+			strconv.Quote(strings.Join(entry.methods, ",")),
+			strconv.Quote(entry.servedBy),
+			latency.Seconds(),
+			orDash(entry.reqID),
+		)
+	default:
+		line := struct {
+			Time      string   `json:"time"`
+			RemoteIP  string   `json:"remote_ip"`
+			Auth      string   `json:"auth,omitempty"`
+			ReqID     string   `json:"req_id,omitempty"`
+			Method    string   `json:"http_method"`
+			Path      string   `json:"path"`
+			UserAgent string   `json:"user_agent,omitempty"`
+			Methods   []string `json:"methods"`
+			ServedBy  string   `json:"served_by,omitempty"`
+			Status    int      `json:"status"`
+			Bytes     int      `json:"bytes"`
+			LatencyMS float64  `json:"latency_ms"`
+		}{
+			Time:      entry.start.UTC().Format(time.RFC3339Nano),
+			RemoteIP:  entry.remoteIP,
+			Auth:      entry.auth,
+			ReqID:     entry.reqID,
+			Method:    entry.httpMethod,
+			Path:      entry.path,
+			UserAgent: entry.userAgent,
+			Methods:   entry.methods,
+			ServedBy:  entry.servedBy,
+			Status:    status,
+			Bytes:     w.size,
+			LatencyMS: float64(latency.Microseconds()) / 1000,
+		}
+		if line.Methods == nil {
+			line.Methods = []string{}
+		}
+		if err := json.NewEncoder(&buf).Encode(line); err != nil {
+			return
+		}
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	_, _ = l.out.Write(buf.Bytes())
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func getAccessLogEntry(ctx context.Context) *accessLogEntry {
+	entry, _ := ctx.Value(ContextKeyAccessLog).(*accessLogEntry)
+	return entry
+}
+
+// recordAccessLogMethod adds an RPC method of the request to its access log line, if enabled
+func recordAccessLogMethod(ctx context.Context, method string) {
+	if entry := getAccessLogEntry(ctx); entry != nil {
+		entry.methods = append(entry.methods, method)
+	}
+}
+
+// recordAccessLogServedBy sets the backends that served the request in its access log line, if enabled
+func recordAccessLogServedBy(ctx context.Context, servedBy string) {
+	if entry := getAccessLogEntry(ctx); entry != nil {
+		entry.servedBy = servedBy
+	}
+}
+
+type accessLogResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *accessLogResponseWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *accessLogResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += n
+	return n, err
+}
@@ -0,0 +1,50 @@
+package proxyd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLogApacheFormat(t *testing.T) {
+	var out bytes.Buffer
+	l, err := NewAccessLogger(AccessLogFormatApache, &out)
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "/secret_key", nil)
+	r.Header.Set("User-Agent", "test-agent")
+	r = mux.SetURLVars(r, map[string]string{"authorization": "secret_key"})
+
+	rw, r, entry := l.begin(httptest.NewRecorder(), r)
+	ctx := r.Context()
+	getAccessLogEntry(ctx).remoteIP = "1.2.3.4"
+	getAccessLogEntry(ctx).auth = "alice"
+	getAccessLogEntry(ctx).reqID = "abc"
+	recordAccessLogMethod(ctx, "eth_chainId")
+	recordAccessLogMethod(ctx, "net_version")
+	recordAccessLogServedBy(ctx, "main/good")
+	rw.WriteHeader(http.StatusTooManyRequests)
+	_, _ = rw.Write([]byte("12345"))
+	l.end(rw, entry)
+
+	line := out.String()
+	require.NotContains(t, line, "secret_key")
+	require.Regexp(t, regexp.MustCompile(
+		`^1\.2\.3\.4 - alice \[[^\]]+\] "POST /\[redacted\] HTTP/1\.1" 429 5 "-" "test-agent" "eth_chainId,net_version" "main/good" \d+\.\d{6} abc\n$`,
+	), line)
+}
+
+func TestAccessLogDisabled(t *testing.T) {
+	// recording into a request without an access log entry is a no-op
+	recordAccessLogMethod(context.Background(), "eth_chainId")
+	recordAccessLogServedBy(context.Background(), "main/good")
+
+	_, err := NewAccessLogger("xml", &bytes.Buffer{})
+	require.Error(t, err)
+}
@@ -10,15 +10,19 @@ import (
 	"io"
 	"math"
 	"math/rand"
+	"net"
 	"net/http"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	sw "github.com/ethereum-optimism/infra/proxyd/pkg/avg-sliding-window"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gorilla/websocket"
@@ -99,6 +103,18 @@ var (
 		HTTPErrorCode: 400,
 	}
 
+	ErrBlockTooOld = &RPCErr{
+		Code:          JSONRPCErrorInternal - 22,
+		Message:       "block is older than the blocks served by this endpoint, use an archive endpoint",
+		HTTPErrorCode: 400,
+	}
+
+	ErrTooManyWSConns = &RPCErr{
+		Code:          JSONRPCErrorInternal - 23,
+		Message:       "too many websocket connections",
+		HTTPErrorCode: 429,
+	}
+
 	ErrRequestBodyTooLarge = &RPCErr{
 		Code:          JSONRPCErrorInternal - 21,
 		Message:       "request body too large",
@@ -147,9 +163,16 @@ type Backend struct {
 	maxResponseSize      int64
 	maxRPS               int
 	maxWSConns           int
+	maxBatchSize         int
+	batchParallelism     int
 	outOfServiceInterval time.Duration
 	stripTrailingXFF     bool
 	proxydIP             string
+	zone                 string
+	tlsCertificatePins   []string
+
+	requestIDHeader     string
+	passthroughClientID bool
 
 	skipPeerCountCheck bool
 	forcedCandidate    bool
@@ -158,11 +181,25 @@ type Backend struct {
 	maxLatencyThreshold         time.Duration
 	maxErrorRateThreshold       float64
 
+	nonRetryableErrors     []string
+	nonRetryableErrorCodes []int
+
+	methodMaxResponseSize map[string]int64
+	slowRequestThreshold  time.Duration
+	normalizeJSONRPC      bool
+	validateResponseIDs   bool
+
+	health      healthHysteresis
+	healthCheck *healthCheck
+
 	latencySlidingWindow            *sw.AvgSlidingWindow
 	networkRequestsSlidingWindow    *sw.AvgSlidingWindow
 	intermittentErrorsSlidingWindow *sw.AvgSlidingWindow
 
 	weight int
+
+	// draining is set once the backend is removed by a config reload, or drained for maintenance
+	draining atomic.Bool
 }
 
 type BackendOpt func(b *Backend)
@@ -198,6 +235,13 @@ func WithMaxResponseSize(size int64) BackendOpt {
 	}
 }
 
+// WithMethodMaxResponseSize overrides the max response size for the given methods
+func WithMethodMaxResponseSize(sizes map[string]int64) BackendOpt {
+	return func(b *Backend) {
+		b.methodMaxResponseSize = sizes
+	}
+}
+
 func WithOutOfServiceDuration(interval time.Duration) BackendOpt {
 	return func(b *Backend) {
 		b.outOfServiceInterval = interval
@@ -216,12 +260,93 @@ func WithMaxWSConns(maxConns int) BackendOpt {
 	}
 }
 
+func WithMaxBatchSize(size int) BackendOpt {
+	return func(b *Backend) {
+		b.maxBatchSize = size
+	}
+}
+
+func WithBatchParallelism(parallelism int) BackendOpt {
+	return func(b *Backend) {
+		b.batchParallelism = parallelism
+	}
+}
+
 func WithTLSConfig(tlsConfig *tls.Config) BackendOpt {
 	return func(b *Backend) {
 		if b.client.Transport == nil {
-			b.client.Transport = &http.Transport{}
+			b.client.Transport = http.DefaultTransport.(*http.Transport).Clone()
+		}
+		if len(b.tlsCertificatePins) > 0 {
+			if tlsConfig == nil {
+				tlsConfig = &tls.Config{}
+			}
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.VerifyPeerCertificate = certificatePinVerifier(b.Name, b.tlsCertificatePins)
 		}
 		b.client.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+		// websocket connections to wss backends must be verified the same way
+		b.dialer.TLSClientConfig = tlsConfig
+	}
+}
+
+// WithTLSCertificatePin only accepts backend TLS connections whose certificate chain contains a
+// certificate with one of the given SHA-256 fingerprints of its DER encoding, hex encoded.
+// Fingerprints are expected to be normalized with NormalizeCertificateFingerprint.
+func WithTLSCertificatePin(fingerprints []string) BackendOpt {
+	return func(b *Backend) {
+		b.tlsCertificatePins = fingerprints
+		var tlsConfig *tls.Config
+		if t, ok := b.client.Transport.(*http.Transport); ok {
+			tlsConfig = t.TLSClientConfig
+		}
+		WithTLSConfig(tlsConfig)(b)
+	}
+}
+
+// TransportConfig tunes the connection reuse of a backend's HTTP transport. Zero values keep
+// the defaults of http.DefaultTransport.
+type TransportConfig struct {
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	// KeepAlive is the interval of TCP keep-alive probes, negative to disable them
+	KeepAlive time.Duration
+	// DisableKeepAlives uses each connection for a single request
+	DisableKeepAlives bool
+}
+
+// WithTransportConfig merges the given connection pool settings into the backend's HTTP transport
+func WithTransportConfig(cfg TransportConfig) BackendOpt {
+	return func(b *Backend) {
+		if b.client.Transport == nil {
+			b.client.Transport = http.DefaultTransport.(*http.Transport).Clone()
+		}
+		t := b.client.Transport.(*http.Transport)
+		if cfg.MaxIdleConnsPerHost != 0 {
+			t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+			if t.MaxIdleConns != 0 && t.MaxIdleConns < cfg.MaxIdleConnsPerHost {
+				t.MaxIdleConns = cfg.MaxIdleConnsPerHost
+			}
+		}
+		if cfg.IdleConnTimeout != 0 {
+			t.IdleConnTimeout = cfg.IdleConnTimeout
+		}
+		if cfg.KeepAlive != 0 {
+			t.DialContext = (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: cfg.KeepAlive,
+			}).DialContext
+		}
+		if cfg.DisableKeepAlives {
+			t.DisableKeepAlives = true
+		}
+	}
+}
+
+// WithZone sets the zone of the backend, used to prefer backends in proxyd's own zone
+func WithZone(zone string) BackendOpt {
+	return func(b *Backend) {
+		b.zone = zone
 	}
 }
 
@@ -267,12 +392,44 @@ func WithMaxLatencyThreshold(maxLatencyThreshold time.Duration) BackendOpt {
 	}
 }
 
+// WithSlowRequestThreshold logs and counts the forwarded requests that take longer than threshold
+func WithSlowRequestThreshold(threshold time.Duration) BackendOpt {
+	return func(b *Backend) {
+		b.slowRequestThreshold = threshold
+	}
+}
+
+// WithHealthHysteresis requires unhealthy readings to persist for unhealthyAfter before the
+// backend is treated as unhealthy, and healthy readings to persist for healthyAfter before it recovers
+func WithHealthHysteresis(unhealthyAfter time.Duration, healthyAfter time.Duration) BackendOpt {
+	return func(b *Backend) {
+		b.health.unhealthyAfter = unhealthyAfter
+		b.health.healthyAfter = healthyAfter
+	}
+}
+
 func WithMaxErrorRateThreshold(maxErrorRateThreshold float64) BackendOpt {
 	return func(b *Backend) {
 		b.maxErrorRateThreshold = maxErrorRateThreshold
 	}
 }
 
+// WithNormalizeJSONRPCVersion sets the jsonrpc field of every backend response to JSONRPCVersion,
+// for strict clients that reject responses where it is missing or different
+func WithNormalizeJSONRPCVersion(normalize bool) BackendOpt {
+	return func(b *Backend) {
+		b.normalizeJSONRPC = normalize
+	}
+}
+
+// WithValidateResponseIDs rejects responses with ErrBackendUnexpectedJSONRPC unless every request ID
+// is answered exactly once, instead of trusting the backend to echo the IDs
+func WithValidateResponseIDs(validate bool) BackendOpt {
+	return func(b *Backend) {
+		b.validateResponseIDs = validate
+	}
+}
+
 func WithConsensusReceiptTarget(receiptsTarget string) BackendOpt {
 	return func(b *Backend) {
 		b.receiptsTarget = receiptsTarget
@@ -285,6 +442,31 @@ func WithIntermittentNetworkErrorSlidingWindow(sw *sw.AvgSlidingWindow) BackendO
 	}
 }
 
+// WithNonRetryableErrors makes Forward give up immediately on errors that contain one of the
+// substrings, or carry one of the JSON-RPC error codes, since retrying them is pointless
+func WithNonRetryableErrors(substrings []string, codes []int) BackendOpt {
+	return func(b *Backend) {
+		b.nonRetryableErrors = substrings
+		b.nonRetryableErrorCodes = codes
+	}
+}
+
+// WithRequestIDHeader injects a request ID into every request sent to the backend, using the given
+// header name. The proxyd request ID is reused so it can be correlated end-to-end.
+func WithRequestIDHeader(headerName string) BackendOpt {
+	return func(b *Backend) {
+		b.requestIDHeader = http.CanonicalHeaderKey(headerName)
+	}
+}
+
+// WithPassthroughClientID uses the request ID sent by the client, if any, instead of
+// generating one. Only applies together with WithRequestIDHeader.
+func WithPassthroughClientID(passthrough bool) BackendOpt {
+	return func(b *Backend) {
+		b.passthroughClientID = passthrough
+	}
+}
+
 type indexedReqRes struct {
 	index int
 	req   *RPCReq
@@ -355,6 +537,10 @@ func (b *Backend) Override(opts ...BackendOpt) {
 }
 
 func (b *Backend) Forward(ctx context.Context, reqs []*RPCReq, isBatch bool) ([]*RPCRes, error) {
+	if maxSize := b.batchSizeLimit(ctx); isBatch && maxSize > 0 && len(reqs) > maxSize {
+		return b.forwardSplitBatch(ctx, reqs, maxSize)
+	}
+
 	var lastError error
 	// <= to account for the first attempt not technically being
 	// a retry
@@ -372,7 +558,7 @@ func (b *Backend) Forward(ctx context.Context, reqs []*RPCReq, isBatch bool) ([]
 			),
 		)
 
-		log.Trace(
+		GetLogger(ctx).Trace(
 			"forwarding request to backend",
 			"name", b.Name,
 			"req_id", GetReqID(ctx),
@@ -384,23 +570,23 @@ func (b *Backend) Forward(ctx context.Context, reqs []*RPCReq, isBatch bool) ([]
 		switch err {
 		case nil: // do nothing
 		case ErrBackendResponseTooLarge:
-			log.Warn(
+			GetLogger(ctx).Warn(
 				"backend response too large",
 				"name", b.Name,
 				"req_id", GetReqID(ctx),
-				"max", b.maxResponseSize,
+				"max", b.responseSizeLimit(reqs),
 				"method", metricLabelMethod,
 			)
 			RecordBatchRPCError(ctx, b.Name, reqs, err)
 		case ErrConsensusGetReceiptsCantBeBatched:
-			log.Warn(
+			GetLogger(ctx).Warn(
 				"Received unsupported batch request for consensus_getReceipts",
 				"name", b.Name,
 				"req_id", GetReqID(ctx),
 				"err", err,
 			)
 		case ErrConsensusGetReceiptsInvalidTarget:
-			log.Error(
+			GetLogger(ctx).Error(
 				"Unsupported consensus_receipts_target for consensus_getReceipts",
 				"name", b.Name,
 				"req_id", GetReqID(ctx),
@@ -411,15 +597,40 @@ func (b *Backend) Forward(ctx context.Context, reqs []*RPCReq, isBatch bool) ([]
 		// We don't label the backend offline in this case. But the error is still returned to
 		// callers so failover can occur if needed.
 		case ErrBackendUnexpectedJSONRPC:
-			log.Debug(
+			GetLogger(ctx).Debug(
 				"Received unexpected JSON-RPC response",
 				"name", b.Name,
 				"req_id", GetReqID(ctx),
 				"err", err,
 			)
 		default:
+			if failFastOnClientError(ctx) && isClientError(err) {
+				GetLogger(ctx).Debug(
+					"backend request failed with client error, not retrying",
+					"name", b.Name,
+					"req_id", GetReqID(ctx),
+					"err", err,
+					"method", metricLabelMethod,
+				)
+				timer.ObserveDuration()
+				RecordBatchRPCError(ctx, b.Name, reqs, err)
+				return nil, err
+			}
+			if b.isNonRetryableError(err) {
+				GetLogger(ctx).Warn(
+					"backend request failed with non-retryable error",
+					"name", b.Name,
+					"req_id", GetReqID(ctx),
+					"err", err,
+					"method", metricLabelMethod,
+					"attempt_count", i+1,
+				)
+				timer.ObserveDuration()
+				RecordBatchRPCError(ctx, b.Name, reqs, err)
+				return nil, wrapErr(err, "non-retryable error forwarding request")
+			}
 			lastError = err
-			log.Warn(
+			GetLogger(ctx).Warn(
 				"backend request failed, trying again",
 				"name", b.Name,
 				"req_id", GetReqID(ctx),
@@ -442,6 +653,66 @@ func (b *Backend) Forward(ctx context.Context, reqs []*RPCReq, isBatch bool) ([]
 	return nil, wrapErr(lastError, "permanent error forwarding request")
 }
 
+// batchSizeLimit returns the largest batch sent to the backend at once: the smaller of the
+// backend's max_batch_size and the max_batch_size of the group splitting the request, or 0
+func (b *Backend) batchSizeLimit(ctx context.Context) int {
+	limit := b.maxBatchSize
+	if groupLimit, _ := ctx.Value(ContextKeyGroupMaxBatchSize).(int); groupLimit > 0 && (limit == 0 || groupLimit < limit) {
+		limit = groupLimit
+	}
+	return limit
+}
+
+// forwardSplitBatch forwards a batch that exceeds the batch limit as several smaller
+// batches, and merges the responses back into the original request order
+func (b *Backend) forwardSplitBatch(ctx context.Context, reqs []*RPCReq, maxSize int) ([]*RPCRes, error) {
+	batches := splitBatch(reqs, maxSize)
+	RecordBatchSplit(b.Name, len(batches))
+
+	parallelism := b.batchParallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	results := make([][]*RPCRes, len(batches))
+	errs := make([]error, len(batches))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, batch []*RPCReq) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = b.Forward(ctx, batch, true)
+		}(i, batch)
+	}
+	wg.Wait()
+
+	res := make([]*RPCRes, 0, len(reqs))
+	for i := range batches {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		res = append(res, results[i]...)
+	}
+	sortBatchRPCResponse(reqs, res)
+	return res, nil
+}
+
+// splitBatch splits reqs into consecutive batches of at most maxSize requests
+func splitBatch(reqs []*RPCReq, maxSize int) [][]*RPCReq {
+	if maxSize <= 0 || len(reqs) <= maxSize {
+		return [][]*RPCReq{reqs}
+	}
+	batches := make([][]*RPCReq, 0, (len(reqs)+maxSize-1)/maxSize)
+	for start := 0; start < len(reqs); start += maxSize {
+		end := min(start+maxSize, len(reqs))
+		batches = append(batches, reqs[start:end])
+	}
+	return batches
+}
+
 func (b *Backend) ProxyWS(clientConn *websocket.Conn, methodWhitelist *StringSet) (*WSProxier, error) {
 	backendConn, _, err := b.dialer.Dial(b.wsURL, nil) // nolint:bodyclose
 	if err != nil {
@@ -482,10 +753,40 @@ func (b *Backend) ForwardRPC(ctx context.Context, res *RPCRes, id string, method
 	return nil
 }
 
+// FetchChainID is a convenient wrapper to retrieve the chain id reported by the backend
+func (b *Backend) FetchChainID(ctx context.Context) (uint64, error) {
+	var rpcRes RPCRes
+	if err := b.ForwardRPC(ctx, &rpcRes, "67", "eth_chainId"); err != nil {
+		return 0, err
+	}
+
+	chainID, ok := rpcRes.Result.(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected response to eth_chainId on backend %s", b.Name)
+	}
+	return hexutil.DecodeUint64(chainID)
+}
+
+// requestID returns the request ID to send to the backend, preferring the client-provided value when
+// passthrough is enabled, then the proxyd request ID, and generating a new one as a last resort
+func (b *Backend) requestID(ctx context.Context) string {
+	if b.passthroughClientID {
+		if id := GetClientHeader(ctx, b.requestIDHeader); id != "" {
+			return id
+		}
+	}
+	if id := GetReqID(ctx); id != "" {
+		return id
+	}
+	return newUUID()
+}
+
 func (b *Backend) doForward(ctx context.Context, rpcReqs []*RPCReq, isBatch bool) ([]*RPCRes, error) {
 	// we are concerned about network error rates, so we record 1 request independently of how many are in the batch
 	b.networkRequestsSlidingWindow.Incr()
 
+	maxResponseSize := b.responseSizeLimit(rpcReqs)
+
 	translatedReqs := make(map[string]*RPCReq, len(rpcReqs))
 	// translate consensus_getReceipts to receipts target
 	// right now we only support non-batched
@@ -576,11 +877,29 @@ func (b *Backend) doForward(ctx context.Context, rpcReqs []*RPCReq, isBatch bool
 	httpReq.Header.Set("content-type", "application/json")
 	httpReq.Header.Set("X-Forwarded-For", xForwardedFor)
 
+	for name, values := range GetForwardedHeaders(ctx) {
+		httpReq.Header[name] = values
+	}
+
+	if b.requestIDHeader != "" {
+		requestID := b.requestID(ctx)
+		httpReq.Header.Set(b.requestIDHeader, requestID)
+		RecordRequestIDInjected(b.Name)
+		GetLogger(ctx).Debug("injected request id",
+			"backend", b.Name,
+			"header", b.requestIDHeader,
+			"request_id", requestID,
+			"req_id", GetReqID(ctx))
+	}
+
 	for name, value := range b.headers {
 		httpReq.Header.Set(name, value)
 	}
 
 	start := time.Now()
+	defer func() {
+		b.maybeRecordSlowRequest(ctx, rpcReqs, isBatch, time.Since(start))
+	}()
 	httpRes, err := b.client.DoLimited(httpReq)
 	if err != nil {
 		b.intermittentErrorsSlidingWindow.Incr()
@@ -604,11 +923,11 @@ func (b *Backend) doForward(ctx context.Context, rpcReqs []*RPCReq, isBatch bool
 	if httpRes.StatusCode != 200 && httpRes.StatusCode != 400 {
 		b.intermittentErrorsSlidingWindow.Incr()
 		RecordBackendNetworkErrorRateSlidingWindow(b, b.ErrorRate())
-		return nil, fmt.Errorf("response code %d", httpRes.StatusCode)
+		return nil, newBackendStatusError(httpRes)
 	}
 
 	defer httpRes.Body.Close()
-	resB, err := io.ReadAll(LimitReader(httpRes.Body, b.maxResponseSize))
+	resB, err := io.ReadAll(LimitReader(httpRes.Body, maxResponseSize))
 	if errors.Is(err, ErrLimitReaderOverLimit) {
 		return nil, ErrBackendResponseTooLarge
 	}
@@ -647,6 +966,17 @@ func (b *Backend) doForward(ctx context.Context, rpcReqs []*RPCReq, isBatch bool
 		return nil, ErrBackendUnexpectedJSONRPC
 	}
 
+	if b.validateResponseIDs && !responseIDsMatch(rpcReqs, rpcRes) {
+		GetLogger(ctx).Warn(
+			"backend response IDs don't match the request IDs",
+			"name", b.Name,
+			"req_id", GetReqID(ctx),
+		)
+		b.intermittentErrorsSlidingWindow.Incr()
+		RecordBackendNetworkErrorRateSlidingWindow(b, b.ErrorRate())
+		return nil, ErrBackendUnexpectedJSONRPC
+	}
+
 	// capture the HTTP status code in the response. this will only
 	// ever be 400 given the status check on line 318 above.
 	if httpRes.StatusCode != 200 {
@@ -672,11 +1002,98 @@ func (b *Backend) doForward(ctx context.Context, rpcReqs []*RPCReq, isBatch bool
 
 	sortBatchRPCResponse(rpcReqs, rpcRes)
 
+	if b.normalizeJSONRPC {
+		for _, res := range rpcRes {
+			res.JSONRPC = JSONRPCVersion
+		}
+	}
+
 	return rpcRes, nil
 }
 
-// IsHealthy checks if the backend is able to serve traffic, based on dynamic parameters
+// maybeRecordSlowRequest logs and counts the request if it took longer than the slow request threshold
+func (b *Backend) maybeRecordSlowRequest(ctx context.Context, rpcReqs []*RPCReq, isBatch bool, duration time.Duration) {
+	if b.slowRequestThreshold == 0 || duration < b.slowRequestThreshold {
+		return
+	}
+	method := rpcReqs[0].Method
+	if isBatch {
+		method = "<batch>"
+	}
+	GetLogger(ctx).Warn(
+		"slow backend request",
+		"name", b.Name,
+		"method", method,
+		"duration", duration,
+		"threshold", b.slowRequestThreshold,
+		"req_id", GetReqID(ctx),
+		"auth", GetAuthCtx(ctx),
+	)
+	RecordSlowBackendRequest(b, method)
+}
+
+// responseSizeLimit returns the max response size for the requests. Requests for methods with
+// their own limit add it to the limit of a batch, and all other requests share the default limit.
+func (b *Backend) responseSizeLimit(reqs []*RPCReq) int64 {
+	if len(b.methodMaxResponseSize) == 0 {
+		return b.maxResponseSize
+	}
+	var limit int64
+	var usesDefault bool
+	for _, req := range reqs {
+		size, ok := b.methodMaxResponseSize[req.Method]
+		if !ok {
+			usesDefault = true
+			continue
+		}
+		limit = saturatingAdd(limit, size)
+	}
+	if usesDefault {
+		limit = saturatingAdd(limit, b.maxResponseSize)
+	}
+	return limit
+}
+
+func saturatingAdd(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+// Drain marks the backend as removed so that it is no longer preferred for new requests,
+// while requests already in flight are allowed to complete
+func (b *Backend) Drain() {
+	b.draining.Store(true)
+	b.StopHealthCheck()
+}
+
+// Undrain resumes sending new requests to a drained backend
+func (b *Backend) Undrain() {
+	if b.draining.CompareAndSwap(true, false) {
+		b.StartHealthCheck()
+	}
+}
+
+func (b *Backend) IsDraining() bool {
+	return b.draining.Load()
+}
+
+// IsHealthy checks if the backend is able to serve traffic, based on dynamic parameters.
+// Readings that flip the health state must persist for the configured hysteresis.
 func (b *Backend) IsHealthy() bool {
+	healthy, changed := b.health.update(b.isInstantHealthy(), time.Now())
+	if changed {
+		RecordBackendEffectiveHealthy(b, healthy)
+	}
+	return healthy
+}
+
+// isInstantHealthy checks the current error rate and latency of the backend
+func (b *Backend) isInstantHealthy() bool {
+	if b.isHealthCheckFailing() {
+		return false
+	}
 	errorRate := b.ErrorRate()
 	avgLatency := time.Duration(b.latencySlidingWindow.Avg())
 	if errorRate >= b.maxErrorRateThreshold {
@@ -688,6 +1105,48 @@ func (b *Backend) IsHealthy() bool {
 	return true
 }
 
+// healthHysteresis smooths the health readings of a backend, so that brief spikes in its
+// error rate or latency don't make it flap in and out of routing
+type healthHysteresis struct {
+	mu             sync.Mutex
+	unhealthyAfter time.Duration
+	healthyAfter   time.Duration
+
+	initialized   bool
+	healthy       bool
+	changingSince time.Time
+}
+
+// update applies a health reading taken at now, and returns the effective health
+// and whether it changed
+func (h *healthHysteresis) update(healthy bool, now time.Time) (bool, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.initialized {
+		h.initialized = true
+		h.healthy = healthy
+		return healthy, true
+	}
+	if healthy == h.healthy {
+		h.changingSince = time.Time{}
+		return h.healthy, false
+	}
+	if h.changingSince.IsZero() {
+		h.changingSince = now
+	}
+	required := h.healthyAfter
+	if h.healthy {
+		required = h.unhealthyAfter
+	}
+	if now.Sub(h.changingSince) < required {
+		return h.healthy, false
+	}
+	h.healthy = healthy
+	h.changingSince = time.Time{}
+	return h.healthy, true
+}
+
 // ErrorRate returns the instant error rate of the backend
 func (b *Backend) ErrorRate() (errorRate float64) {
 	// we only really start counting the error rate after a minimum of 10 requests
@@ -704,6 +1163,117 @@ func (b *Backend) IsDegraded() bool {
 	return avgLatency >= b.maxDegradedLatencyThreshold
 }
 
+// maxStatusErrorBodySize bounds how much of an unexpected status response is read to look for a JSON-RPC error
+const maxStatusErrorBodySize = 4096
+
+// BackendStatusError is returned when a backend responds with an unexpected HTTP status code.
+// RPCErr holds the JSON-RPC error of the response body, if there was one.
+type BackendStatusError struct {
+	StatusCode int
+	RPCErr     *RPCErr
+}
+
+func (e *BackendStatusError) Error() string {
+	return fmt.Sprintf("response code %d", e.StatusCode)
+}
+
+func newBackendStatusError(httpRes *http.Response) *BackendStatusError {
+	defer httpRes.Body.Close()
+	statusErr := &BackendStatusError{StatusCode: httpRes.StatusCode}
+	body, err := io.ReadAll(io.LimitReader(httpRes.Body, maxStatusErrorBodySize))
+	if err != nil {
+		return statusErr
+	}
+	var res RPCRes
+	if err := json.Unmarshal(body, &res); err == nil && res.Error != nil {
+		statusErr.RPCErr = res.Error
+	}
+	return statusErr
+}
+
+const ContextKeyFailFastOnClientError = "fail_fast_on_client_error"
+
+// ContextKeyGroupMaxBatchSize carries the max_batch_size of a backend group that splits larger
+// batches, so backends split them like batches over their own max_batch_size
+const ContextKeyGroupMaxBatchSize = "group_max_batch_size"
+
+// failFastOnClientError reports whether the backend group of a request returns client errors
+// without retrying them
+func failFastOnClientError(ctx context.Context) bool {
+	enabled, _ := ctx.Value(ContextKeyFailFastOnClientError).(bool)
+	return enabled
+}
+
+// requestRPCErrorCodes are the JSON-RPC errors for a malformed request, which every backend
+// returns alike
+var requestRPCErrorCodes = map[int]bool{
+	-32700: true, // parse error
+	-32600: true, // invalid request
+	-32602: true, // invalid params
+}
+
+// isClientError reports whether err is a 4xx response of the backend rejecting the request itself
+// with a JSON-RPC request error, which any backend would return for the same request. Statuses
+// caused by the backend's own setup, like bad credentials, a wrong path or a body size limit, as
+// well as timeouts and rate limits, are specific to the backend. 400 responses don't get here as
+// they are parsed as JSON-RPC responses.
+func isClientError(err error) bool {
+	var statusErr *BackendStatusError
+	if !errors.As(err, &statusErr) || statusErr.RPCErr == nil {
+		return false
+	}
+	switch statusErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusMethodNotAllowed,
+		http.StatusRequestTimeout, http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return false
+	}
+	return statusErr.StatusCode >= 400 && statusErr.StatusCode < 500 && requestRPCErrorCodes[statusErr.RPCErr.Code]
+}
+
+// clientErrorResponses turns the client error of a backend into responses to reqs, keeping its
+// status code and JSON-RPC error
+func clientErrorResponses(reqs []*RPCReq, err error) []*RPCRes {
+	var statusErr *BackendStatusError
+	errors.As(err, &statusErr)
+	res := make([]*RPCRes, 0, len(reqs))
+	for _, req := range reqs {
+		rpcErr := &RPCErr{
+			Code:    JSONRPCErrorInternal,
+			Message: http.StatusText(statusErr.StatusCode),
+		}
+		if statusErr.RPCErr != nil {
+			rpcErr = statusErr.RPCErr.Clone()
+		}
+		rpcErr.HTTPErrorCode = statusErr.StatusCode
+		res = append(res, NewRPCErrorRes(req.ID, rpcErr))
+	}
+	return res
+}
+
+// isNonRetryableError reports whether err matches the backend's non-retryable error substrings or codes
+func (b *Backend) isNonRetryableError(err error) bool {
+	if len(b.nonRetryableErrors) == 0 && len(b.nonRetryableErrorCodes) == 0 {
+		return false
+	}
+
+	msgs := []string{err.Error()}
+	var statusErr *BackendStatusError
+	if errors.As(err, &statusErr) && statusErr.RPCErr != nil {
+		if slices.Contains(b.nonRetryableErrorCodes, statusErr.RPCErr.Code) {
+			return true
+		}
+		msgs = append(msgs, statusErr.RPCErr.Message)
+	}
+	for _, substr := range b.nonRetryableErrors {
+		for _, msg := range msgs {
+			if strings.Contains(msg, substr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func responseIsNotBatched(b []byte) bool {
 	var r RPCRes
 	return json.Unmarshal(b, &r) == nil
@@ -727,6 +1297,21 @@ func sortBatchRPCResponse(req []*RPCReq, res []*RPCRes) {
 	})
 }
 
+// responseIDsMatch reports whether every request is answered by exactly one response with its ID
+func responseIDsMatch(reqs []*RPCReq, res []*RPCRes) bool {
+	pending := make(map[string]bool, len(reqs))
+	for _, r := range reqs {
+		pending[string(r.ID)] = true
+	}
+	for _, r := range res {
+		if !pending[string(r.ID)] {
+			return false
+		}
+		delete(pending, string(r.ID))
+	}
+	return len(pending) == 0
+}
+
 type BackendGroup struct {
 	Name                   string
 	Backends               []*Backend
@@ -735,26 +1320,267 @@ type BackendGroup struct {
 	FallbackBackends       map[string]bool
 	routingStrategy        RoutingStrategy
 	multicallRPCErrorCheck bool
+	responseValidation     *ValidationRuleSet
+	errorNormalizer        *ErrorNormalizer
+	wsClientSideFiltering  bool
+	fallbackGroup          *BackendGroup
+	accountsIntercept      map[string]json.RawMessage
+	shadow                 *shadowBackend
+	localZone              string
+
+	// backendsMtx guards Backends and FallbackBackends, which are replaced (never mutated
+	// in place) when backends are added or removed on a config reload
+	backendsMtx sync.RWMutex
+
+	maxBatchSize int
+	onOversize   OversizePolicy
+
+	failFastOnClientError bool
+
+	canary *canary
+
+	consensusLatencyWeighted bool
+}
+
+type BackendGroupOpt func(bg *BackendGroup)
+
+// WithWSClientSideFiltering drops log notifications that don't match the client's
+// eth_subscribe filter before they are forwarded, in case the backend sends overly broad events
+func WithWSClientSideFiltering(enabled bool) BackendGroupOpt {
+	return func(bg *BackendGroup) {
+		bg.wsClientSideFiltering = enabled
+	}
+}
+
+// WithAccountsIntercept sets the account methods answered by proxyd on WS connections, and their results
+func WithAccountsIntercept(intercept map[string]json.RawMessage) BackendGroupOpt {
+	return func(bg *BackendGroup) {
+		bg.accountsIntercept = intercept
+	}
+}
+
+// WithErrorNormalizer rewrites the errors returned by the group's backends to canonical errors
+func WithErrorNormalizer(normalizer *ErrorNormalizer) BackendGroupOpt {
+	return func(bg *BackendGroup) {
+		bg.errorNormalizer = normalizer
+	}
+}
+
+// WithLocalZone makes the group prefer backends in the given zone over equally healthy backends in other zones
+func WithLocalZone(zone string) BackendGroupOpt {
+	return func(bg *BackendGroup) {
+		bg.localZone = zone
+	}
+}
+
+// WithConsensusLatencyWeighted sends proportionally more traffic to consensus group members with
+// a lower average latency, instead of shuffling them uniformly
+func WithConsensusLatencyWeighted(enabled bool) BackendGroupOpt {
+	return func(bg *BackendGroup) {
+		bg.consensusLatencyWeighted = enabled
+	}
+}
+
+// WithGroupMaxBatchSize limits the number of calls of a batch routed to the group, either
+// rejecting or splitting larger batches
+func WithGroupMaxBatchSize(size int, policy OversizePolicy) BackendGroupOpt {
+	return func(bg *BackendGroup) {
+		bg.maxBatchSize = size
+		bg.onOversize = policy
+	}
+}
+
+// rejectsBatch reports whether a batch of size calls exceeds the group's limit and must be rejected
+func (bg *BackendGroup) rejectsBatch(size int) bool {
+	return bg.maxBatchSize > 0 && size > bg.maxBatchSize && bg.onOversize == OversizeReject
+}
+
+// splitBatchSize returns the size batches forwarded to the group's backends are split to, or 0
+func (bg *BackendGroup) splitBatchSize() int {
+	if bg.onOversize != OversizeSplit {
+		return 0
+	}
+	return bg.maxBatchSize
+}
+
+// WithFailFastOnClientError returns the first 4xx client error of a backend to the client,
+// without retries or failover
+func WithFailFastOnClientError(enabled bool) BackendGroupOpt {
+	return func(bg *BackendGroup) {
+		bg.failFastOnClientError = enabled
+	}
+}
+
+func (bg *BackendGroup) Override(opts ...BackendGroupOpt) {
+	for _, opt := range opts {
+		opt(bg)
+	}
 }
 
 func (bg *BackendGroup) GetRoutingStrategy() RoutingStrategy {
 	return bg.routingStrategy
 }
 
+// backends returns a snapshot of the group's backends and their fallback status
+func (bg *BackendGroup) backends() ([]*Backend, map[string]bool) {
+	bg.backendsMtx.RLock()
+	defer bg.backendsMtx.RUnlock()
+	return bg.Backends, bg.FallbackBackends
+}
+
+// AddBackend adds a backend to the group, replacing any existing backend with the same name
+func (bg *BackendGroup) AddBackend(be *Backend, fallback bool) {
+	bg.backendsMtx.Lock()
+	defer bg.backendsMtx.Unlock()
+
+	backends := make([]*Backend, 0, len(bg.Backends)+1)
+	for _, existing := range bg.Backends {
+		if existing.Name != be.Name {
+			backends = append(backends, existing)
+		}
+	}
+	fallbackBackends := make(map[string]bool, len(bg.FallbackBackends)+1)
+	for name, fb := range bg.FallbackBackends {
+		fallbackBackends[name] = fb
+	}
+	fallbackBackends[be.Name] = fallback
+
+	bg.Backends = append(backends, be)
+	bg.FallbackBackends = fallbackBackends
+}
+
+// RemoveBackend removes the named backend from the group and returns it, or nil if the group
+// doesn't contain it. Requests already in flight to the backend are allowed to complete.
+func (bg *BackendGroup) RemoveBackend(name string) *Backend {
+	bg.backendsMtx.Lock()
+	defer bg.backendsMtx.Unlock()
+
+	var removed *Backend
+	backends := make([]*Backend, 0, len(bg.Backends))
+	for _, existing := range bg.Backends {
+		if existing.Name == name {
+			removed = existing
+			continue
+		}
+		backends = append(backends, existing)
+	}
+	if removed == nil {
+		return nil
+	}
+	fallbackBackends := make(map[string]bool, len(bg.FallbackBackends))
+	for n, fb := range bg.FallbackBackends {
+		if n != name {
+			fallbackBackends[n] = fb
+		}
+	}
+
+	bg.Backends = backends
+	bg.FallbackBackends = fallbackBackends
+	return removed
+}
+
 func (bg *BackendGroup) Fallbacks() []*Backend {
+	backends, fallbackBackends := bg.backends()
 	fallbacks := []*Backend{}
-	for _, a := range bg.Backends {
-		if fallback, ok := bg.FallbackBackends[a.Name]; ok && fallback {
+	for _, a := range backends {
+		if fallback, ok := fallbackBackends[a.Name]; ok && fallback {
 			fallbacks = append(fallbacks, a)
 		}
 	}
 	return fallbacks
 }
 
+// StartDrain stops sending new requests to the named backend, while requests already in
+// flight are allowed to complete. Unlike an offline backend, it isn't used as a last resort.
+// Draining applies to the backend, so it is drained in every group it belongs to.
+func (bg *BackendGroup) StartDrain(name string) error {
+	be := bg.backendByName(name)
+	if be == nil {
+		return fmt.Errorf("backend %s is not part of backend group %s", name, bg.Name)
+	}
+	if !be.IsDraining() {
+		log.Info("draining backend", "backend_name", name, "backend_group", bg.Name)
+	}
+	be.Drain()
+	RecordBackendDraining(bg, name, true)
+	return nil
+}
+
+// StopDrain resumes sending new requests to the named backend
+func (bg *BackendGroup) StopDrain(name string) error {
+	be := bg.backendByName(name)
+	if be == nil {
+		return fmt.Errorf("backend %s is not part of backend group %s", name, bg.Name)
+	}
+	if be.IsDraining() {
+		log.Info("stopped draining backend", "backend_name", name, "backend_group", bg.Name)
+	}
+	be.Undrain()
+	RecordBackendDraining(bg, name, false)
+	return nil
+}
+
+// SetDrained drains exactly the named backends of the group, stopping the drain of its other
+// backends. Names of backends outside the group are ignored.
+func (bg *BackendGroup) SetDrained(names []string) error {
+	backends, _ := bg.backends()
+	for _, be := range backends {
+		var err error
+		if slices.Contains(names, be.Name) {
+			err = bg.StartDrain(be.Name)
+		} else if be.IsDraining() {
+			err = bg.StopDrain(be.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsDrained returns whether the named backend is excluded from new requests
+func (bg *BackendGroup) IsDrained(name string) bool {
+	be := bg.backendByName(name)
+	return be != nil && be.IsDraining()
+}
+
+func (bg *BackendGroup) backendByName(name string) *Backend {
+	backends, _ := bg.backends()
+	for _, be := range backends {
+		if be.Name == name {
+			return be
+		}
+	}
+	return nil
+}
+
+// withoutDrained returns the backends that aren't drained
+func (bg *BackendGroup) withoutDrained(backends []*Backend) []*Backend {
+	serving := make([]*Backend, 0, len(backends))
+	for _, be := range backends {
+		if !be.IsDraining() {
+			serving = append(serving, be)
+		}
+	}
+	return serving
+}
+
+// HasHealthyBackend returns true if any backend of the group is healthy and not degraded
+func (bg *BackendGroup) HasHealthyBackend() bool {
+	backends, _ := bg.backends()
+	for _, be := range backends {
+		if be.IsHealthy() && !be.IsDegraded() {
+			return true
+		}
+	}
+	return false
+}
+
 func (bg *BackendGroup) Primaries() []*Backend {
+	backends, fallbackBackends := bg.backends()
 	primaries := []*Backend{}
-	for _, a := range bg.Backends {
-		fallback, ok := bg.FallbackBackends[a.Name]
+	for _, a := range backends {
+		fallback, ok := fallbackBackends[a.Name]
 		if ok && !fallback {
 			primaries = append(primaries, a)
 		}
@@ -768,6 +1594,16 @@ func (bg *BackendGroup) Forward(ctx context.Context, rpcReqs []*RPCReq, isBatch
 		return nil, "", nil
 	}
 
+	if isBatch {
+		methods := RecordBatchComposition(bg, rpcReqs)
+		GetLogger(ctx).Debug("forwarding batch",
+			"req_id", GetReqID(ctx),
+			"backend_group", bg.Name,
+			"batch_size", len(rpcReqs),
+			"methods", methods,
+		)
+	}
+
 	backends := bg.orderedBackendsForRequest()
 
 	overriddenResponses := make([]*indexedReqRes, 0)
@@ -798,7 +1634,7 @@ func (bg *BackendGroup) Forward(ctx context.Context, rpcReqs []*RPCReq, isBatch
 	backendResp := <-ch
 
 	if backendResp.error != nil {
-		log.Error("error serving requests",
+		GetLogger(ctx).Error("error serving requests",
 			"req_id", GetReqID(ctx),
 			"auth", GetAuthCtx(ctx),
 			"err", backendResp.error,
@@ -806,8 +1642,10 @@ func (bg *BackendGroup) Forward(ctx context.Context, rpcReqs []*RPCReq, isBatch
 		return backendResp.RPCRes, backendResp.ServedBy, backendResp.error
 	}
 
+	bg.maybeMirrorToShadow(ctx, rpcReqs, isBatch, backendResp.RPCRes)
+
 	// re-apply overridden responses
-	log.Trace("successfully served request overriding responses",
+	GetLogger(ctx).Trace("successfully served request overriding responses",
 		"req_id", GetReqID(ctx),
 		"auth", GetAuthCtx(ctx),
 	)
@@ -815,6 +1653,40 @@ func (bg *BackendGroup) Forward(ctx context.Context, rpcReqs []*RPCReq, isBatch
 	return res, backendResp.ServedBy, backendResp.error
 }
 
+// ForwardWithGroupFailover forwards the requests to the group and, if none of its backends
+// can serve them, retries once against the configured fallback group
+func (bg *BackendGroup) ForwardWithGroupFailover(ctx context.Context, rpcReqs []*RPCReq, isBatch bool) ([]*RPCRes, string, error) {
+	if bg.fallbackGroup == nil {
+		return bg.Forward(ctx, rpcReqs, isBatch)
+	}
+
+	// each group may rewrite the requests (e.g. consensus block tags), so the
+	// fallback group must receive the requests as originally sent by the client
+	res, servedBy, err := bg.Forward(ctx, copyRPCReqs(rpcReqs), isBatch)
+	if !errors.Is(err, ErrNoBackends) {
+		return res, servedBy, err
+	}
+
+	GetLogger(ctx).Warn(
+		"backend group unserviceable, failing over to fallback group",
+		"backend_group", bg.Name,
+		"fallback_group", bg.fallbackGroup.Name,
+		"req_id", GetReqID(ctx),
+		"auth", GetAuthCtx(ctx),
+	)
+	RecordBackendGroupFailover(bg, bg.fallbackGroup)
+	return bg.fallbackGroup.Forward(ctx, rpcReqs, isBatch)
+}
+
+func copyRPCReqs(rpcReqs []*RPCReq) []*RPCReq {
+	copied := make([]*RPCReq, len(rpcReqs))
+	for i, req := range rpcReqs {
+		c := *req
+		copied[i] = &c
+	}
+	return copied
+}
+
 func isValidMulticallTx(rpcReqs []*RPCReq) bool {
 	if len(rpcReqs) == 1 {
 		if rpcReqs[0].Method == "eth_sendRawTransaction" {
@@ -836,20 +1708,22 @@ func (bg *BackendGroup) ExecuteMulticall(ctx context.Context, rpcReqs []*RPCReq)
 	// after original request returns
 	bgCtx := context.WithoutCancel(ctx)
 
-	log.Info("executing multicall routing strategy",
+	GetLogger(ctx).Info("executing multicall routing strategy",
 		"req_id", GetReqID(bgCtx),
 		"auth", GetAuthCtx(bgCtx),
 	)
+	backends, _ := bg.backends()
+	backends = bg.withoutDrained(backends)
 	var wg sync.WaitGroup
-	ch := make(chan *multicallTuple, len(bg.Backends))
-	for _, backend := range bg.Backends {
+	ch := make(chan *multicallTuple, len(backends))
+	for _, backend := range backends {
 		wg.Add(1)
 		go bg.MulticallRequest(backend, rpcReqs, &wg, bgCtx, ch)
 	}
 
 	go func() {
 		wg.Wait()
-		log.Debug("closing multicall channel",
+		GetLogger(ctx).Debug("closing multicall channel",
 			"req_id", GetReqID(bgCtx),
 			"auth", GetAuthCtx(bgCtx),
 		)
@@ -861,7 +1735,7 @@ func (bg *BackendGroup) ExecuteMulticall(ctx context.Context, rpcReqs []*RPCReq)
 
 func (bg *BackendGroup) MulticallRequest(backend *Backend, rpcReqs []*RPCReq, wg *sync.WaitGroup, ctx context.Context, ch chan *multicallTuple) {
 	defer wg.Done()
-	log.Debug("forwarding multicall request to backend",
+	GetLogger(ctx).Debug("forwarding multicall request to backend",
 		"req_id", GetReqID(ctx),
 		"auth", GetAuthCtx(ctx),
 		"backend", backend.Name,
@@ -875,7 +1749,7 @@ func (bg *BackendGroup) MulticallRequest(backend *Backend, rpcReqs []*RPCReq, wg
 		backendName: backend.Name,
 	}
 
-	log.Debug("placing multicall response into channel",
+	GetLogger(ctx).Debug("placing multicall response into channel",
 		"req_id", GetReqID(ctx),
 		"auth", GetAuthCtx(ctx),
 		"backend", backend.Name,
@@ -883,14 +1757,14 @@ func (bg *BackendGroup) MulticallRequest(backend *Backend, rpcReqs []*RPCReq, wg
 
 	ch <- multicallResp
 
-	log.Trace("placed multicall response into channel",
+	GetLogger(ctx).Trace("placed multicall response into channel",
 		"req_id", GetReqID(ctx),
 		"auth", GetAuthCtx(ctx),
 		"backend", backend.Name,
 	)
 
 	if backendResp.error != nil {
-		log.Error("received multicall error response from backend",
+		GetLogger(ctx).Error("received multicall error response from backend",
 			"req_id", GetReqID(ctx),
 			"auth", GetAuthCtx(ctx),
 			"backend", backend.Name,
@@ -908,7 +1782,7 @@ func (bg *BackendGroup) ProcessMulticallResponses(ch chan *multicallTuple, ctx c
 	for {
 		multicallResp, ok := <-ch
 		if !ok {
-			log.Trace("multicall response channel closed",
+			GetLogger(ctx).Trace("multicall response channel closed",
 				"req_id", GetReqID(ctx),
 				"auth", GetAuthCtx(ctx),
 				"response_count", i,
@@ -928,7 +1802,7 @@ func (bg *BackendGroup) ProcessMulticallResponses(ch chan *multicallTuple, ctx c
 		backendName := multicallResp.backendName
 
 		if resp.error != nil {
-			log.Error("received error response from multicall channel",
+			GetLogger(ctx).Error("received error response from multicall channel",
 				"req_id", GetReqID(ctx),
 				"auth", GetAuthCtx(ctx),
 				"err", resp.error,
@@ -944,7 +1818,7 @@ func (bg *BackendGroup) ProcessMulticallResponses(ch chan *multicallTuple, ctx c
 			continue
 		}
 
-		log.Info("received successful response from multicall channel",
+		GetLogger(ctx).Info("received successful response from multicall channel",
 			"req_id", GetReqID(ctx),
 			"auth", GetAuthCtx(ctx),
 			"served_by", resp.ServedBy,
@@ -955,10 +1829,11 @@ func (bg *BackendGroup) ProcessMulticallResponses(ch chan *multicallTuple, ctx c
 }
 
 func (bg *BackendGroup) ProxyWS(ctx context.Context, clientConn *websocket.Conn, methodWhitelist *StringSet) (*WSProxier, error) {
-	for _, back := range bg.Backends {
+	backends, _ := bg.backends()
+	for _, back := range bg.withoutDrained(backends) {
 		proxier, err := back.ProxyWS(clientConn, methodWhitelist)
 		if errors.Is(err, ErrBackendOffline) {
-			log.Warn(
+			GetLogger(ctx).Warn(
 				"skipping offline backend",
 				"name", back.Name,
 				"req_id", GetReqID(ctx),
@@ -967,7 +1842,7 @@ func (bg *BackendGroup) ProxyWS(ctx context.Context, clientConn *websocket.Conn,
 			continue
 		}
 		if errors.Is(err, ErrBackendOverCapacity) {
-			log.Warn(
+			GetLogger(ctx).Warn(
 				"skipping over-capacity backend",
 				"name", back.Name,
 				"req_id", GetReqID(ctx),
@@ -976,7 +1851,7 @@ func (bg *BackendGroup) ProxyWS(ctx context.Context, clientConn *websocket.Conn,
 			continue
 		}
 		if err != nil {
-			log.Warn(
+			GetLogger(ctx).Warn(
 				"error dialing ws backend",
 				"name", back.Name,
 				"req_id", GetReqID(ctx),
@@ -985,6 +1860,12 @@ func (bg *BackendGroup) ProxyWS(ctx context.Context, clientConn *websocket.Conn,
 			)
 			continue
 		}
+		if bg.wsClientSideFiltering {
+			proxier.filters = newWSSubscriptionFilters()
+		}
+		if bg.accountsIntercept != nil {
+			proxier.accountsIntercept = bg.accountsIntercept
+		}
 		return proxier, nil
 	}
 
@@ -999,27 +1880,87 @@ func weightedShuffle(backends []*Backend) {
 	weightedshuffle.ShuffleInplace(backends, weight, nil)
 }
 
+// minLatencyWeightRatio is the smallest weight a backend can be given relative to the fastest
+// backend, so that slow backends keep receiving enough traffic for their latency data to stay fresh
+const minLatencyWeightRatio = 0.05
+
+// latencyWeights returns the selection weight of each backend, inversely proportional to its
+// average latency. Backends without latency data are weighted like the fastest backend.
+func latencyWeights(backends []*Backend) []float64 {
+	weights := make([]float64, len(backends))
+	maxWeight := 0.0
+	for i, be := range backends {
+		if avg := be.latencySlidingWindow.Avg(); avg > 0 {
+			weights[i] = 1 / avg
+			maxWeight = max(maxWeight, weights[i])
+		}
+	}
+	if maxWeight == 0 {
+		maxWeight = 1
+	}
+	for i := range weights {
+		if weights[i] == 0 {
+			weights[i] = maxWeight
+		}
+		weights[i] = max(weights[i], maxWeight*minLatencyWeightRatio)
+	}
+	return weights
+}
+
+func latencyWeightedShuffle(backends []*Backend) {
+	// the shuffle swaps elements in place, so weights are looked up by backend rather than index
+	weights := make(map[*Backend]float64, len(backends))
+	for i, w := range latencyWeights(backends) {
+		weights[backends[i]] = w
+	}
+	weight := func(i int) float64 {
+		return weights[backends[i]]
+	}
+
+	weightedshuffle.ShuffleInplace(backends, weight, nil)
+}
+
 func (bg *BackendGroup) orderedBackendsForRequest() []*Backend {
+	return bg.withoutDrained(bg.candidateBackendsForRequest())
+}
+
+func (bg *BackendGroup) candidateBackendsForRequest() []*Backend {
 	if bg.Consensus != nil {
 		return bg.loadBalancedConsensusGroup()
 	} else {
-		healthy := make([]*Backend, 0, len(bg.Backends))
-		unhealthy := make([]*Backend, 0, len(bg.Backends))
-		for _, be := range bg.Backends {
+		backends, _ := bg.backends()
+		healthy := make([]*Backend, 0, len(backends))
+		unhealthy := make([]*Backend, 0, len(backends))
+		for _, be := range backends {
 			if be.IsHealthy() {
 				healthy = append(healthy, be)
 			} else {
 				unhealthy = append(unhealthy, be)
 			}
 		}
-		if bg.WeightedRouting {
+		if bg.GetRoutingStrategy() == LatencyWeightedRoutingStrategy {
+			latencyWeightedShuffle(healthy)
+		} else if bg.WeightedRouting {
 			weightedShuffle(healthy)
 			weightedShuffle(unhealthy)
 		}
+		bg.preferLocalZone(healthy)
+		bg.preferLocalZone(unhealthy)
 		return append(healthy, unhealthy...)
 	}
 }
 
+// preferLocalZone moves the backends in the group's local zone ahead of the others,
+// keeping the relative order within each zone
+func (bg *BackendGroup) preferLocalZone(backends []*Backend) {
+	if bg.localZone == "" {
+		return
+	}
+	sort.SliceStable(backends, func(i, j int) bool {
+		return backends[i].zone == bg.localZone && backends[j].zone != bg.localZone
+	})
+}
+
 func (bg *BackendGroup) loadBalancedConsensusGroup() []*Backend {
 	cg := bg.Consensus.GetConsensusGroup()
 
@@ -1047,10 +1988,16 @@ func (bg *BackendGroup) loadBalancedConsensusGroup() []*Backend {
 		backendsDegraded[i], backendsDegraded[j] = backendsDegraded[j], backendsDegraded[i]
 	})
 
-	if bg.WeightedRouting {
+	if bg.consensusLatencyWeighted {
+		latencyWeightedShuffle(backendsHealthy)
+		latencyWeightedShuffle(backendsDegraded)
+	} else if bg.WeightedRouting {
 		weightedShuffle(backendsHealthy)
 	}
 
+	bg.preferLocalZone(backendsHealthy)
+	bg.preferLocalZone(backendsDegraded)
+
 	// healthy are put into a priority position
 	// degraded backends are used as fallback
 	backendsHealthy = append(backendsHealthy, backendsDegraded...)
@@ -1062,6 +2009,7 @@ func (bg *BackendGroup) Shutdown() {
 	if bg.Consensus != nil {
 		bg.Consensus.Shutdown()
 	}
+	bg.StopCanary()
 }
 
 func calcBackoff(i int) time.Duration {
@@ -1079,6 +2027,9 @@ type WSProxier struct {
 	methodWhitelist *StringSet
 	readTimeout     time.Duration
 	writeTimeout    time.Duration
+	filters         *wsSubscriptionFilters
+	// accountsIntercept maps the account methods answered by proxyd to their result
+	accountsIntercept map[string]json.RawMessage
 }
 
 func NewWSProxier(backend *Backend, clientConn, backendConn *websocket.Conn, methodWhitelist *StringSet) *WSProxier {
@@ -1089,6 +2040,8 @@ func NewWSProxier(backend *Backend, clientConn, backendConn *websocket.Conn, met
 		methodWhitelist: methodWhitelist,
 		readTimeout:     defaultWSReadTimeout,
 		writeTimeout:    defaultWSWriteTimeout,
+
+		accountsIntercept: defaultAccountsInterceptResponses,
 	}
 }
 
@@ -1107,7 +2060,7 @@ func (w *WSProxier) clientPump(ctx context.Context, errC chan error) {
 		msgType, msg, err := w.clientConn.ReadMessage()
 		if err != nil {
 			if err := w.writeBackendConn(websocket.CloseMessage, formatWSError(err)); err != nil {
-				log.Error("error writing backendConn message", "err", err)
+				GetLogger(ctx).Error("error writing backendConn message", "err", err)
 				errC <- err
 				return
 			}
@@ -1138,7 +2091,7 @@ func (w *WSProxier) clientPump(ctx context.Context, errC chan error) {
 				id = req.ID
 				method = req.Method
 			}
-			log.Info(
+			GetLogger(ctx).Info(
 				"error preparing client message",
 				"auth", GetAuthCtx(ctx),
 				"req_id", GetReqID(ctx),
@@ -1156,10 +2109,11 @@ func (w *WSProxier) clientPump(ctx context.Context, errC chan error) {
 			continue
 		}
 
-		// Send eth_accounts requests directly to the client
-		if req.Method == "eth_accounts" {
-			msg = mustMarshalJSON(NewRPCRes(req.ID, emptyArrayResponse))
-			RecordRPCForward(ctx, BackendProxyd, "eth_accounts", RPCRequestSourceWS)
+		// Answer account requests directly to the client
+		if result, ok := w.accountsIntercept[req.Method]; ok {
+			msg = mustMarshalJSON(NewRPCRes(req.ID, result))
+			RecordRPCForward(ctx, BackendProxyd, req.Method, RPCRequestSourceWS)
+			RecordAccountsIntercepted(req.Method)
 			err = w.writeClientConn(msgType, msg)
 			if err != nil {
 				errC <- err
@@ -1168,8 +2122,19 @@ func (w *WSProxier) clientPump(ctx context.Context, errC chan error) {
 			continue
 		}
 
+		if w.filters != nil {
+			if err := w.filters.TrackRequest(req); err != nil {
+				GetLogger(ctx).Debug(
+					"unable to parse subscription filter",
+					"auth", GetAuthCtx(ctx),
+					"req_id", GetReqID(ctx),
+					"err", err,
+				)
+			}
+		}
+
 		RecordRPCForward(ctx, w.backend.Name, req.Method, RPCRequestSourceWS)
-		log.Info(
+		GetLogger(ctx).Info(
 			"forwarded WS message to backend",
 			"method", req.Method,
 			"auth", GetAuthCtx(ctx),
@@ -1190,7 +2155,7 @@ func (w *WSProxier) backendPump(ctx context.Context, errC chan error) {
 		msgType, msg, err := w.backendConn.ReadMessage()
 		if err != nil {
 			if err := w.writeClientConn(websocket.CloseMessage, formatWSError(err)); err != nil {
-				log.Error("error writing clientConn message", "err", err)
+				GetLogger(ctx).Error("error writing clientConn message", "err", err)
 				errC <- err
 				return
 			}
@@ -1208,17 +2173,29 @@ func (w *WSProxier) backendPump(ctx context.Context, errC chan error) {
 			continue
 		}
 
+		if w.filters != nil && !w.filters.ShouldForward(msg) {
+			GetLogger(ctx).Debug(
+				"dropped log notification not matching subscription filter",
+				"auth", GetAuthCtx(ctx),
+				"req_id", GetReqID(ctx),
+			)
+			continue
+		}
+
 		res, err := w.parseBackendMsg(msg)
+		if w.filters != nil && err == nil {
+			w.filters.TrackResponse(res)
+		}
 		if err != nil {
 			var id json.RawMessage
 			if res != nil {
 				id = res.ID
 			}
 			msg = mustMarshalJSON(NewRPCErrorRes(id, err))
-			log.Info("backend responded with error", "err", err)
+			GetLogger(ctx).Info("backend responded with error", "err", err)
 		} else {
 			if res.IsError() {
-				log.Info(
+				GetLogger(ctx).Info(
 					"backend responded with RPC error",
 					"code", res.Error.Code,
 					"msg", res.Error.Message,
@@ -1228,7 +2205,7 @@ func (w *WSProxier) backendPump(ctx context.Context, errC chan error) {
 				)
 				RecordRPCError(ctx, w.backend.Name, MethodUnknown, res.Error)
 			} else {
-				log.Info(
+				GetLogger(ctx).Info(
 					"forwarded WS message to client",
 					"auth", GetAuthCtx(ctx),
 					"req_id", GetReqID(ctx),
@@ -1341,7 +2318,7 @@ func RecordBatchRPCError(ctx context.Context, backendName string, reqs []*RPCReq
 }
 
 func MaybeRecordErrorsInRPCRes(ctx context.Context, backendName string, reqs []*RPCReq, resBatch []*RPCRes) {
-	log.Debug("forwarded RPC request",
+	GetLogger(ctx).Debug("forwarded RPC request",
 		"backend", backendName,
 		"auth", GetAuthCtx(ctx),
 		"req_id", GetReqID(ctx),
@@ -1357,7 +2334,7 @@ func MaybeRecordErrorsInRPCRes(ctx context.Context, backendName string, reqs []*
 	}
 
 	if lastError != nil {
-		log.Info(
+		GetLogger(ctx).Info(
 			"backend responded with RPC error",
 			"backend", backendName,
 			"last_error_code", lastError.Code,
@@ -1397,6 +2374,12 @@ func (bg *BackendGroup) ForwardRequestToBackendGroup(
 	ctx context.Context,
 	isBatch bool,
 ) *BackendGroupRPCResponse {
+	if bg.failFastOnClientError {
+		ctx = context.WithValue(ctx, ContextKeyFailFastOnClientError, true) // nolint:staticcheck
+	}
+	if size := bg.splitBatchSize(); size > 0 {
+		ctx = context.WithValue(ctx, ContextKeyGroupMaxBatchSize, size) // nolint:staticcheck
+	}
 	for _, back := range backends {
 		res := make([]*RPCRes, 0)
 		var err error
@@ -1424,7 +2407,7 @@ func (bg *BackendGroup) ForwardRequestToBackendGroup(
 				}
 			}
 			if errors.Is(err, ErrBackendOffline) {
-				log.Warn(
+				GetLogger(ctx).Warn(
 					"skipping offline backend",
 					"name", back.Name,
 					"auth", GetAuthCtx(ctx),
@@ -1433,7 +2416,7 @@ func (bg *BackendGroup) ForwardRequestToBackendGroup(
 				continue
 			}
 			if errors.Is(err, ErrBackendOverCapacity) {
-				log.Warn(
+				GetLogger(ctx).Warn(
 					"skipping over-capacity backend",
 					"name", back.Name,
 					"auth", GetAuthCtx(ctx),
@@ -1441,8 +2424,22 @@ func (bg *BackendGroup) ForwardRequestToBackendGroup(
 				)
 				continue
 			}
+			if bg.failFastOnClientError && isClientError(err) {
+				GetLogger(ctx).Info(
+					"returning backend client error without failover",
+					"name", back.Name,
+					"req_id", GetReqID(ctx),
+					"auth", GetAuthCtx(ctx),
+					"err", err,
+				)
+				return &BackendGroupRPCResponse{
+					RPCRes:   clientErrorResponses(rpcReqs, err),
+					ServedBy: servedBy,
+					error:    nil,
+				}
+			}
 			if err != nil {
-				log.Error(
+				GetLogger(ctx).Error(
 					"error forwarding request to backend",
 					"name", back.Name,
 					"req_id", GetReqID(ctx),
@@ -1451,6 +2448,15 @@ func (bg *BackendGroup) ForwardRequestToBackendGroup(
 				)
 				continue
 			}
+			if !bg.validateResponse(ctx, back, rpcReqs, res) {
+				continue
+			}
+			if bg.errorNormalizer != nil {
+				bg.errorNormalizer.Normalize(ctx, res)
+			}
+			if bg.localZone != "" && back.zone != bg.localZone {
+				RecordCrossZoneRequest(bg, back)
+			}
 		}
 
 		return &BackendGroupRPCResponse{
@@ -1469,6 +2475,31 @@ func (bg *BackendGroup) ForwardRequestToBackendGroup(
 
 }
 
+// validateResponse applies the group's response validation rules. It returns false
+// if the response is invalid and the next backend should be tried instead.
+func (bg *BackendGroup) validateResponse(ctx context.Context, back *Backend, rpcReqs []*RPCReq, res []*RPCRes) bool {
+	if bg.responseValidation == nil {
+		return true
+	}
+	method, rule, err := bg.responseValidation.Validate(rpcReqs, res)
+	if err == nil {
+		return true
+	}
+
+	RecordResponseValidationFailure(method, rule)
+	GetLogger(ctx).Warn(
+		"backend response failed validation",
+		"name", back.Name,
+		"method", method,
+		"rule", rule,
+		"mode", bg.responseValidation.Mode(),
+		"req_id", GetReqID(ctx),
+		"auth", GetAuthCtx(ctx),
+		"err", err,
+	)
+	return bg.responseValidation.Mode() != ResponseValidationStrict
+}
+
 func OverrideResponses(res []*RPCRes, overriddenResponses []*indexedReqRes) []*RPCRes {
 	for _, ov := range overriddenResponses {
 		if len(res) > 0 {
@@ -1487,6 +2518,7 @@ func (bg *BackendGroup) OverwriteConsensusResponses(rpcReqs []*RPCReq, overridde
 		safe:          bg.Consensus.GetSafeBlockNumber(),
 		finalized:     bg.Consensus.GetFinalizedBlockNumber(),
 		maxBlockRange: bg.Consensus.maxBlockRange,
+		maxBlockDepth: bg.Consensus.maxBlockDepth,
 	}
 
 	for i, req := range rpcReqs {
@@ -1501,6 +2533,8 @@ func (bg *BackendGroup) OverwriteConsensusResponses(rpcReqs []*RPCReq, overridde
 			})
 			if errors.Is(err, ErrRewriteBlockOutOfRange) {
 				res.Error = ErrBlockOutOfRange
+			} else if errors.Is(err, ErrRewriteBlockTooOld) {
+				res.Error = ErrBlockTooOld
 			} else if errors.Is(err, ErrRewriteRangeTooLarge) {
 				res.Error = ErrInvalidParams(
 					fmt.Sprintf("block range greater than %d max", rctx.maxBlockRange),
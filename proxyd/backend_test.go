@@ -1,9 +1,20 @@
 package proxyd
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sw "github.com/ethereum-optimism/infra/proxyd/pkg/avg-sliding-window"
 )
 
 func TestStripXFF(t *testing.T) {
@@ -20,3 +31,338 @@ func TestStripXFF(t *testing.T) {
 		assert.Equal(t, test.out, actual)
 	}
 }
+
+func TestSplitBatch(t *testing.T) {
+	reqs := make([]*RPCReq, 7)
+	for i := range reqs {
+		reqs[i] = &RPCReq{ID: json.RawMessage(strconv.Itoa(i)), Method: "eth_chainId"}
+	}
+
+	tests := []struct {
+		maxSize int
+		sizes   []int
+	}{
+		{0, []int{7}},
+		{7, []int{7}},
+		{10, []int{7}},
+		{3, []int{3, 3, 1}},
+		{1, []int{1, 1, 1, 1, 1, 1, 1}},
+	}
+
+	for _, test := range tests {
+		batches := splitBatch(reqs, test.maxSize)
+		require.Len(t, batches, len(test.sizes))
+		var flattened []*RPCReq
+		for i, batch := range batches {
+			assert.Len(t, batch, test.sizes[i])
+			flattened = append(flattened, batch...)
+		}
+		assert.Equal(t, reqs, flattened)
+	}
+}
+
+func TestSplitBatchMergePreservesOrder(t *testing.T) {
+	reqs := make([]*RPCReq, 10)
+	for i := range reqs {
+		reqs[i] = &RPCReq{ID: json.RawMessage(strconv.Itoa(i)), Method: "eth_chainId"}
+	}
+
+	// sub-batches complete out of order and backends may reorder responses within a batch
+	var res []*RPCRes
+	batches := splitBatch(reqs, 4)
+	for i := len(batches) - 1; i >= 0; i-- {
+		for j := len(batches[i]) - 1; j >= 0; j-- {
+			res = append(res, &RPCRes{ID: batches[i][j].ID, Result: string(batches[i][j].ID)})
+		}
+	}
+
+	sortBatchRPCResponse(reqs, res)
+	require.Len(t, res, len(reqs))
+	for i := range reqs {
+		assert.Equal(t, reqs[i].ID, res[i].ID)
+	}
+}
+
+func TestLatencyWeights(t *testing.T) {
+	newBackend := func(latencies ...time.Duration) *Backend {
+		b := &Backend{latencySlidingWindow: sw.NewSlidingWindow()}
+		for _, l := range latencies {
+			b.latencySlidingWindow.Add(float64(l))
+		}
+		return b
+	}
+
+	fast := newBackend(10 * time.Millisecond)
+	slow := newBackend(20 * time.Millisecond)
+	outlier := newBackend(10 * time.Second)
+	fresh := newBackend()
+
+	weights := latencyWeights([]*Backend{fast, slow, outlier, fresh})
+	require.Len(t, weights, 4)
+	// inversely proportional to latency
+	assert.InDelta(t, 2, weights[0]/weights[1], 0.0001)
+	// the outlier keeps a minimum share of the fastest backend's weight
+	assert.InDelta(t, minLatencyWeightRatio, weights[2]/weights[0], 0.0001)
+	// backends without latency data are treated like the fastest backend
+	assert.Equal(t, weights[0], weights[3])
+}
+
+func TestLatencyWeightsNoData(t *testing.T) {
+	backends := []*Backend{
+		{latencySlidingWindow: sw.NewSlidingWindow()},
+		{latencySlidingWindow: sw.NewSlidingWindow()},
+	}
+	weights := latencyWeights(backends)
+	assert.Equal(t, []float64{1, 1}, weights)
+}
+
+func TestConsensusLatencyWeighted(t *testing.T) {
+	fast := NewBackend("fast", "http://fast", "", nil)
+	fast.latencySlidingWindow.Add(float64(10 * time.Millisecond))
+	slow := NewBackend("slow", "http://slow", "", nil)
+	slow.latencySlidingWindow.Add(float64(time.Second))
+
+	bg := &BackendGroup{
+		Name:     "main",
+		Backends: []*Backend{fast, slow},
+	}
+	bg.Consensus = &ConsensusPoller{consensusGroup: []*Backend{fast, slow}}
+	bg.Override(WithConsensusLatencyWeighted(true))
+
+	fastFirst := 0
+	for i := 0; i < 1000; i++ {
+		ordered := bg.loadBalancedConsensusGroup()
+		require.Len(t, ordered, 2)
+		if ordered[0] == fast {
+			fastFirst++
+		}
+	}
+	// the slow backend only keeps minLatencyWeightRatio of the fast backend's weight
+	assert.Greater(t, fastFirst, 800)
+}
+
+func TestOrderedBackendsZoneAffinity(t *testing.T) {
+	localA := NewBackend("local-a", "http://local-a", "", nil, WithZone("zone-1"))
+	remoteB := NewBackend("remote-b", "http://remote-b", "", nil, WithZone("zone-2"))
+	localC := NewBackend("local-c", "http://local-c", "", nil, WithZone("zone-1"))
+	unzonedD := NewBackend("unzoned-d", "http://unzoned-d", "", nil)
+
+	bg := &BackendGroup{
+		Name:     "main",
+		Backends: []*Backend{remoteB, localA, unzonedD, localC},
+	}
+
+	t.Run("no local zone keeps order", func(t *testing.T) {
+		require.Equal(t, []*Backend{remoteB, localA, unzonedD, localC}, bg.orderedBackendsForRequest())
+	})
+
+	bg.Override(WithLocalZone("zone-1"))
+
+	t.Run("local backends first", func(t *testing.T) {
+		require.Equal(t, []*Backend{localA, localC, remoteB, unzonedD}, bg.orderedBackendsForRequest())
+	})
+
+	t.Run("cross zone fallback when local backends are unhealthy", func(t *testing.T) {
+		// above the default max latency threshold
+		localA.latencySlidingWindow.Add(float64(20 * time.Second))
+		localC.latencySlidingWindow.Add(float64(20 * time.Second))
+		require.Equal(t, []*Backend{remoteB, unzonedD, localA, localC}, bg.orderedBackendsForRequest())
+	})
+}
+
+func TestBackendGroupDrain(t *testing.T) {
+	a := NewBackend("a", "http://a", "", nil)
+	b := NewBackend("b", "http://b", "", nil)
+	bg := &BackendGroup{
+		Name:     "main",
+		Backends: []*Backend{a, b},
+	}
+
+	require.NoError(t, bg.StartDrain("a"))
+	require.True(t, bg.IsDrained("a"))
+	require.True(t, a.IsDraining())
+	require.Equal(t, 1.0, testutil.ToFloat64(backendDraining.WithLabelValues("main", "a")))
+	// drained backends are excluded, not ordered last like unhealthy ones
+	require.Equal(t, []*Backend{b}, bg.orderedBackendsForRequest())
+
+	require.NoError(t, bg.StopDrain("a"))
+	require.False(t, bg.IsDrained("a"))
+	require.False(t, a.IsDraining())
+	require.Equal(t, 0.0, testutil.ToFloat64(backendDraining.WithLabelValues("main", "a")))
+	require.Equal(t, []*Backend{a, b}, bg.orderedBackendsForRequest())
+
+	require.Error(t, bg.StartDrain("unknown"))
+
+	require.NoError(t, bg.SetDrained([]string{"b"}))
+	require.Equal(t, []*Backend{a}, bg.orderedBackendsForRequest())
+	require.NoError(t, bg.SetDrained(nil))
+	require.Equal(t, []*Backend{a, b}, bg.orderedBackendsForRequest())
+}
+
+func TestBatchCompositionMetrics(t *testing.T) {
+	RegisterBatchMethods([]string{"eth_chainId", "eth_call"})
+	bg := &BackendGroup{Name: "batch-composition"}
+
+	reqs := []*RPCReq{
+		{Method: "eth_chainId", ID: json.RawMessage("1")},
+		{Method: "eth_call", ID: json.RawMessage("2")},
+		{Method: "eth_call", ID: json.RawMessage("3")},
+		{Method: "eth_unmapped", ID: json.RawMessage("4")},
+	}
+	_, _, err := bg.Forward(context.Background(), reqs, true)
+	require.ErrorIs(t, err, ErrNoBackends)
+
+	// each method is counted once per batch, unknown methods as other
+	require.Equal(t, 1.0, testutil.ToFloat64(batchForwardMethodsTotal.WithLabelValues(bg.Name, "eth_chainId")))
+	require.Equal(t, 1.0, testutil.ToFloat64(batchForwardMethodsTotal.WithLabelValues(bg.Name, "eth_call")))
+	require.Equal(t, 1.0, testutil.ToFloat64(batchForwardMethodsTotal.WithLabelValues(bg.Name, MethodOther)))
+
+	// single requests aren't recorded
+	_, _, err = bg.Forward(context.Background(), reqs[:1], false)
+	require.ErrorIs(t, err, ErrNoBackends)
+	require.Equal(t, 1.0, testutil.ToFloat64(batchForwardMethodsTotal.WithLabelValues(bg.Name, "eth_chainId")))
+}
+
+func TestResponseSizeLimit(t *testing.T) {
+	b := NewBackend("b", "http://b", "", nil,
+		WithMaxResponseSize(1000),
+		WithMethodMaxResponseSize(map[string]int64{"eth_blockNumber": 10, "eth_getLogs": 5000}),
+	)
+
+	reqs := func(methods ...string) []*RPCReq {
+		out := make([]*RPCReq, len(methods))
+		for i, method := range methods {
+			out[i] = &RPCReq{Method: method, ID: json.RawMessage(strconv.Itoa(i))}
+		}
+		return out
+	}
+
+	require.Equal(t, int64(10), b.responseSizeLimit(reqs("eth_blockNumber")))
+	require.Equal(t, int64(1000), b.responseSizeLimit(reqs("eth_call")))
+	// batches add up method limits, and share the default limit between other methods
+	require.Equal(t, int64(20), b.responseSizeLimit(reqs("eth_blockNumber", "eth_blockNumber")))
+	require.Equal(t, int64(1000), b.responseSizeLimit(reqs("eth_call", "eth_chainId")))
+	require.Equal(t, int64(6010), b.responseSizeLimit(reqs("eth_getLogs", "eth_blockNumber", "eth_call")))
+
+	unlimited := NewBackend("unlimited", "http://unlimited", "", nil,
+		WithMethodMaxResponseSize(map[string]int64{"eth_blockNumber": 10}),
+	)
+	require.Equal(t, int64(math.MaxInt64), unlimited.responseSizeLimit(reqs("eth_blockNumber", "eth_call")))
+}
+
+func TestHealthHysteresis(t *testing.T) {
+	h := &healthHysteresis{unhealthyAfter: 10 * time.Second, healthyAfter: 30 * time.Second}
+	start := time.Now()
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+
+	healthy, changed := h.update(true, at(0))
+	require.True(t, healthy)
+	require.True(t, changed)
+
+	// brief spikes don't flip the health
+	for _, s := range []int{1, 5, 9} {
+		healthy, changed = h.update(false, at(s))
+		require.True(t, healthy)
+		require.False(t, changed)
+	}
+	h.update(true, at(10))
+	healthy, _ = h.update(false, at(12))
+	require.True(t, healthy, "a healthy reading restarts the window")
+
+	// sustained unhealthy readings do
+	healthy, changed = h.update(false, at(22))
+	require.False(t, healthy)
+	require.True(t, changed)
+
+	// and it takes longer to recover
+	healthy, _ = h.update(true, at(23))
+	require.False(t, healthy)
+	healthy, _ = h.update(true, at(50))
+	require.False(t, healthy)
+	healthy, changed = h.update(true, at(53))
+	require.True(t, healthy)
+	require.True(t, changed)
+}
+
+func TestHealthHysteresisDisabled(t *testing.T) {
+	h := &healthHysteresis{}
+	now := time.Now()
+
+	h.update(true, now)
+	healthy, changed := h.update(false, now)
+	require.False(t, healthy)
+	require.True(t, changed)
+	healthy, changed = h.update(true, now)
+	require.True(t, healthy)
+	require.True(t, changed)
+}
+
+func TestWithTransportConfig(t *testing.T) {
+	b := NewBackend("test", "http://localhost:8545", "", nil,
+		WithTransportConfig(TransportConfig{
+			MaxIdleConnsPerHost: 200,
+			IdleConnTimeout:     45 * time.Second,
+			KeepAlive:           15 * time.Second,
+		}),
+		WithTLSConfig(&tls.Config{ServerName: "backend"}),
+	)
+
+	transport, ok := b.client.Transport.(*http.Transport)
+	require.True(t, ok)
+	require.Equal(t, 200, transport.MaxIdleConnsPerHost)
+	require.Equal(t, 200, transport.MaxIdleConns)
+	require.Equal(t, 45*time.Second, transport.IdleConnTimeout)
+	require.NotNil(t, transport.DialContext)
+	require.False(t, transport.DisableKeepAlives)
+	// the TLS config is merged into the same transport
+	require.Equal(t, "backend", transport.TLSClientConfig.ServerName)
+	// unset values keep the defaults
+	require.Equal(t, http.DefaultTransport.(*http.Transport).TLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+
+	b = NewBackend("test", "http://localhost:8545", "", nil,
+		WithTLSConfig(&tls.Config{}),
+		WithTransportConfig(TransportConfig{DisableKeepAlives: true}),
+	)
+	transport = b.client.Transport.(*http.Transport)
+	require.True(t, transport.DisableKeepAlives)
+	require.NotNil(t, transport.TLSClientConfig)
+	// a transport created for the TLS config also starts from the defaults
+	require.NotNil(t, transport.Proxy)
+	require.NotNil(t, transport.DialContext)
+	require.Equal(t, http.DefaultTransport.(*http.Transport).IdleConnTimeout, transport.IdleConnTimeout)
+}
+
+func TestResponseIDsMatch(t *testing.T) {
+	reqs := []*RPCReq{{ID: []byte("1")}, {ID: []byte(`"a"`)}}
+	res := func(ids ...string) []*RPCRes {
+		out := make([]*RPCRes, 0, len(ids))
+		for _, id := range ids {
+			out = append(out, &RPCRes{ID: []byte(id)})
+		}
+		return out
+	}
+
+	require.True(t, responseIDsMatch(reqs, res("1", `"a"`)))
+	require.True(t, responseIDsMatch(reqs, res(`"a"`, "1")))
+	require.False(t, responseIDsMatch(reqs, res("1", "2")))
+	require.False(t, responseIDsMatch(reqs, res("1", "1")))
+	require.False(t, responseIDsMatch(reqs, res("1", "a")))
+}
+
+func TestBatchSizeLimit(t *testing.T) {
+	unlimited := NewBackend("unlimited", "http://unlimited", "", nil)
+	limited := NewBackend("limited", "http://limited", "", nil, WithMaxBatchSize(5))
+
+	ctx := context.Background()
+	require.Equal(t, 0, unlimited.batchSizeLimit(ctx))
+	require.Equal(t, 5, limited.batchSizeLimit(ctx))
+
+	// the smaller of the backend and group limits applies
+	groupCtx := context.WithValue(ctx, ContextKeyGroupMaxBatchSize, 3) // nolint:staticcheck
+	require.Equal(t, 3, unlimited.batchSizeLimit(groupCtx))
+	require.Equal(t, 3, limited.batchSizeLimit(groupCtx))
+	groupCtx = context.WithValue(ctx, ContextKeyGroupMaxBatchSize, 10) // nolint:staticcheck
+	require.Equal(t, 5, limited.batchSizeLimit(groupCtx))
+}
@@ -191,6 +191,23 @@ func newRPCCache(cache Cache) RPCCache {
 	}
 }
 
+// enableStaleWhileRevalidate caches the given methods in cache, serving their entries stale for staleTTL
+// after they expire while they're refreshed in the background. cache must keep entries for ttl+staleTTL.
+func (c *rpcCache) enableStaleWhileRevalidate(cache Cache, methods []string, ttl time.Duration, staleTTL time.Duration) {
+	for _, method := range methods {
+		c.handlers[method] = NewStaleWhileRevalidateHandler(cache, ttl, staleTTL)
+	}
+}
+
+// setRefresher sets the function used to refresh stale entries
+func (c *rpcCache) setRefresher(refresh RPCCacheRefresher) {
+	for _, handler := range c.handlers {
+		if h, ok := handler.(*StaleWhileRevalidateHandler); ok {
+			h.refresh = refresh
+		}
+	}
+}
+
 func (c *rpcCache) GetRPC(ctx context.Context, req *RPCReq) (*RPCRes, error) {
 	handler := c.handlers[req.Method]
 	if handler == nil {
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -261,3 +264,55 @@ func TestFallbackCache(t *testing.T) {
 		})
 	}
 }
+
+func TestRPCCacheStaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+
+	cache := newRPCCache(newMemoryCache()).(*rpcCache)
+	cache.enableStaleWhileRevalidate(newMemoryCache(), []string{"eth_gasPrice"}, time.Minute, time.Minute)
+	handler := cache.handlers["eth_gasPrice"].(*StaleWhileRevalidateHandler)
+
+	now := time.Now()
+	handler.now = func() time.Time { return now }
+
+	refreshed := make(chan struct{})
+	var refreshes atomic.Int32
+	cache.setRefresher(func(ctx context.Context, req *RPCReq) (*RPCRes, error) {
+		refreshes.Add(1)
+		<-refreshed
+		return &RPCRes{JSONRPC: "2.0", Result: "0x2", ID: req.ID}, nil
+	})
+
+	ID := []byte(strconv.Itoa(1))
+	req := &RPCReq{JSONRPC: "2.0", Method: "eth_gasPrice", ID: ID}
+	require.NoError(t, cache.PutRPC(ctx, req, &RPCRes{JSONRPC: "2.0", Result: "0x1", ID: ID}))
+
+	// fresh entries are served without a refresh
+	res, err := cache.GetRPC(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "0x1", res.Result)
+	require.Equal(t, int32(0), refreshes.Load())
+
+	// stale entries are served while a single refresh runs in the background
+	now = now.Add(90 * time.Second)
+	staleHits := testutil.ToFloat64(cacheStaleHitsTotal.WithLabelValues("eth_gasPrice"))
+	for i := 0; i < 3; i++ {
+		res, err = cache.GetRPC(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, "0x1", res.Result)
+	}
+	require.Equal(t, staleHits+3, testutil.ToFloat64(cacheStaleHitsTotal.WithLabelValues("eth_gasPrice")))
+
+	close(refreshed)
+	require.Eventually(t, func() bool {
+		res, err := cache.GetRPC(ctx, req)
+		return err == nil && res != nil && res.Result == "0x2"
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, int32(1), refreshes.Load())
+
+	// entries past the stale window are a miss
+	now = now.Add(3 * time.Minute)
+	res, err = cache.GetRPC(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, res)
+}
@@ -0,0 +1,197 @@
+package proxyd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	CanaryOutcomeMatch    = "match"
+	CanaryOutcomeDiverged = "diverged"
+	CanaryOutcomeError    = "error"
+)
+
+// canary periodically sends a fixed set of requests to every backend of a group, and reports the
+// backends whose responses diverge from the others on data that should be identical
+type canary struct {
+	requests []*RPCReq
+	interval time.Duration
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// NewCanaryRequests builds the canary requests of a backend group from their config
+func NewCanaryRequests(cfgs []CanaryRequestConfig) ([]*RPCReq, error) {
+	reqs := make([]*RPCReq, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		if cfg.Method == "" {
+			return nil, fmt.Errorf("canary request %d has no method", i)
+		}
+		params := json.RawMessage("[]")
+		if cfg.Params != "" {
+			var p []interface{}
+			if err := json.Unmarshal([]byte(cfg.Params), &p); err != nil {
+				return nil, fmt.Errorf("params of canary request %d must be a JSON array: %w", i, err)
+			}
+			params = json.RawMessage(cfg.Params)
+		}
+		reqs = append(reqs, &RPCReq{
+			JSONRPC: JSONRPCVersion,
+			Method:  cfg.Method,
+			Params:  params,
+			ID:      json.RawMessage("1"),
+		})
+	}
+	return reqs, nil
+}
+
+// WithCanary sends the given requests to every backend of the group each interval, comparing
+// the responses of the backends
+func WithCanary(requests []*RPCReq, interval time.Duration) BackendGroupOpt {
+	return func(bg *BackendGroup) {
+		bg.canary = &canary{
+			requests: requests,
+			interval: interval,
+		}
+	}
+}
+
+// StartCanary starts sending the canary requests in the background, if a canary is configured
+func (bg *BackendGroup) StartCanary() {
+	c := bg.canary
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			bg.runCanary(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// StopCanary stops sending the canary requests
+func (bg *BackendGroup) StopCanary() {
+	c := bg.canary
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+}
+
+func (bg *BackendGroup) runCanary(ctx context.Context) {
+	c := bg.canary
+	ctx, cancel := context.WithTimeout(ctx, c.interval)
+	defer cancel()
+
+	backends, _ := bg.backends()
+	diverged := make(map[string]bool, len(backends))
+	for _, req := range c.requests {
+		results := make(map[string]*RPCRes, len(backends))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, back := range backends {
+			wg.Add(1)
+			go func(back *Backend) {
+				defer wg.Done()
+				reqCopy := *req
+				res, err := back.doForward(ctx, []*RPCReq{&reqCopy}, false)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil || len(res) != 1 || res[0].IsError() {
+					results[back.Name] = nil
+					return
+				}
+				results[back.Name] = res[0]
+			}(back)
+		}
+		wg.Wait()
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return
+		}
+
+		outcomes := canaryOutcomes(results)
+		for name, outcome := range outcomes {
+			RecordCanaryCheck(bg.Name, name, req.Method, outcome)
+			if outcome == CanaryOutcomeDiverged {
+				diverged[name] = true
+				log.Warn("backend diverged on canary request",
+					"group", bg.Name,
+					"backend", name,
+					"method", req.Method,
+					"params", string(req.Params),
+				)
+			}
+		}
+	}
+
+	for _, back := range backends {
+		RecordCanaryDiverged(bg.Name, back.Name, diverged[back.Name])
+	}
+}
+
+// canaryOutcomes compares the results of the backends for a canary request, keyed by backend name
+// with nil for failed requests. Results that differ from the majority of the successful backends
+// diverged, and without a majority every backend diverged.
+func canaryOutcomes(results map[string]*RPCRes) map[string]string {
+	keys := make(map[string]string, len(results))
+	counts := make(map[string]int)
+	for name, res := range results {
+		if res == nil {
+			continue
+		}
+		key, err := json.Marshal(res.Result)
+		if err != nil {
+			continue
+		}
+		keys[name] = string(key)
+		counts[string(key)]++
+	}
+
+	var majority string
+	for key, count := range counts {
+		if count*2 > len(keys) {
+			majority = key
+		}
+	}
+
+	outcomes := make(map[string]string, len(results))
+	for name := range results {
+		key, ok := keys[name]
+		switch {
+		case !ok:
+			outcomes[name] = CanaryOutcomeError
+		case len(counts) == 1 || key == majority:
+			outcomes[name] = CanaryOutcomeMatch
+		default:
+			outcomes[name] = CanaryOutcomeDiverged
+		}
+	}
+	return outcomes
+}
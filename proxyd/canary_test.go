@@ -0,0 +1,108 @@
+package proxyd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+)
+
+func TestCanary(t *testing.T) {
+	newCanaryBackend := func(name string, hash string) *Backend {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hash == "" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","result":{"hash":"%s"},"id":1}`, hash)
+		}))
+		t.Cleanup(srv.Close)
+		return NewBackend(name, srv.URL, "", semaphore.NewWeighted(10), WithStrippedTrailingXFF())
+	}
+
+	reqs, err := NewCanaryRequests([]CanaryRequestConfig{
+		{Method: "eth_getBlockByNumber", Params: `["0x1", false]`},
+	})
+	require.NoError(t, err)
+
+	bg := &BackendGroup{
+		Name: "canary",
+		Backends: []*Backend{
+			newCanaryBackend("canary-a", "0xaa"),
+			newCanaryBackend("canary-b", "0xaa"),
+			newCanaryBackend("canary-c", "0xcc"),
+			newCanaryBackend("canary-d", ""),
+		},
+	}
+	bg.Override(WithCanary(reqs, time.Second))
+	bg.runCanary(context.Background())
+
+	diverged := func(name string) float64 {
+		return testutil.ToFloat64(canaryDivergedBackends.WithLabelValues("canary", name))
+	}
+	checks := func(name string, outcome string) float64 {
+		return testutil.ToFloat64(canaryChecksTotal.WithLabelValues("canary", name, "eth_getBlockByNumber", outcome))
+	}
+	require.Equal(t, 0.0, diverged("canary-a"))
+	require.Equal(t, 0.0, diverged("canary-b"))
+	require.Equal(t, 1.0, diverged("canary-c"))
+	require.Equal(t, 0.0, diverged("canary-d"))
+	require.Equal(t, 1.0, checks("canary-a", CanaryOutcomeMatch))
+	require.Equal(t, 1.0, checks("canary-c", CanaryOutcomeDiverged))
+	require.Equal(t, 1.0, checks("canary-d", CanaryOutcomeError))
+}
+
+func TestCanaryOutcomes(t *testing.T) {
+	res := func(result interface{}) *RPCRes {
+		return NewRPCRes([]byte("1"), result)
+	}
+
+	tests := []struct {
+		name     string
+		results  map[string]*RPCRes
+		expected map[string]string
+	}{
+		{
+			name:     "all match",
+			results:  map[string]*RPCRes{"a": res("0x1"), "b": res("0x1")},
+			expected: map[string]string{"a": CanaryOutcomeMatch, "b": CanaryOutcomeMatch},
+		},
+		{
+			name:     "minority diverges",
+			results:  map[string]*RPCRes{"a": res("0x1"), "b": res("0x1"), "c": res("0x2")},
+			expected: map[string]string{"a": CanaryOutcomeMatch, "b": CanaryOutcomeMatch, "c": CanaryOutcomeDiverged},
+		},
+		{
+			name:     "no majority",
+			results:  map[string]*RPCRes{"a": res("0x1"), "b": res("0x2")},
+			expected: map[string]string{"a": CanaryOutcomeDiverged, "b": CanaryOutcomeDiverged},
+		},
+		{
+			name:     "errors are not compared",
+			results:  map[string]*RPCRes{"a": res("0x1"), "b": nil},
+			expected: map[string]string{"a": CanaryOutcomeMatch, "b": CanaryOutcomeError},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, canaryOutcomes(tt.results))
+		})
+	}
+}
+
+func TestNewCanaryRequests(t *testing.T) {
+	reqs, err := NewCanaryRequests([]CanaryRequestConfig{{Method: "eth_chainId"}})
+	require.NoError(t, err)
+	require.Equal(t, "[]", string(reqs[0].Params))
+
+	_, err = NewCanaryRequests([]CanaryRequestConfig{{Method: "eth_getBlockByNumber", Params: `{"block": 1}`}})
+	require.Error(t, err)
+	_, err = NewCanaryRequests([]CanaryRequestConfig{{Params: `[]`}})
+	require.Error(t, err)
+}
@@ -62,15 +62,22 @@ func main() {
 		}()
 	}
 
-	_, shutdown, err := proxyd.Start(config)
+	srv, shutdown, err := proxyd.Start(config)
 	if err != nil {
 		log.Crit("error starting proxyd", "err", err)
 	}
 
+	stopReload := srv.ReloadOnSignal(func() (*proxyd.Config, error) {
+		config := new(proxyd.Config)
+		_, err := toml.DecodeFile(os.Args[1], config)
+		return config, err
+	})
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
 	recvSig := <-sig
 	log.Info("caught signal, shutting down", "signal", recvSig)
+	stopReload()
 	shutdown()
 }
 
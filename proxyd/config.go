@@ -29,11 +29,49 @@ type ServerConfig struct {
 	EnablePprof           bool `toml:"enable_pprof"`
 	EnableXServedByHeader bool `toml:"enable_served_by_header"`
 	AllowAllOrigins       bool `toml:"allow_all_origins"`
+
+	// ForwardedHeaders lists inbound request headers that are propagated to backends
+	ForwardedHeaders []string `toml:"forwarded_headers"`
+
+	// HealthCheckRequireConsensus makes /healthz also require a non-empty consensus group
+	// for consensus aware backend groups
+	HealthCheckRequireConsensus bool `toml:"health_check_require_consensus"`
+
+	// LocalZone is the zone proxyd runs in. Backends with the same zone are preferred over
+	// equally healthy backends in other zones.
+	LocalZone string `toml:"local_zone"`
+
+	// RewriteRequestIDs forwards batches with internal IDs unique within each batch, and maps
+	// the responses back to the client IDs. Duplicate and null IDs then share a batch.
+	RewriteRequestIDs bool `toml:"rewrite_request_ids"`
+
+	// MaxWSConnsPerClient limits the concurrent websocket connections of a client, identified
+	// by its authentication or else its IP. Disabled if 0.
+	MaxWSConnsPerClient int `toml:"max_ws_conns_per_client"`
+}
+
+type LoggingConfig struct {
+	// SampleRate is the fraction of requests (0 to 1) whose trace, debug and info logs
+	// are emitted. Warnings and errors are always logged. Disabled by default.
+	SampleRate float64 `toml:"sample_rate"`
+}
+
+type AccessLogConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Format is json or apache, default json
+	Format AccessLogFormat `toml:"format"`
+	// Output is stdout, stderr or the path of a file that is appended to, default stdout
+	Output string `toml:"output"`
 }
 
 type CacheConfig struct {
 	Enabled bool         `toml:"enabled"`
 	TTL     TOMLDuration `toml:"ttl"`
+
+	// StaleWhileRevalidate caches the responses of StaleWhileRevalidateMethods for TTL, and keeps
+	// serving them for this long after that while they're refreshed in the background
+	StaleWhileRevalidate        TOMLDuration `toml:"stale_while_revalidate"`
+	StaleWhileRevalidateMethods []string     `toml:"stale_while_revalidate_methods"`
 }
 
 type RedisConfig struct {
@@ -53,6 +91,8 @@ type MetricsConfig struct {
 	Enabled bool   `toml:"enabled"`
 	Host    string `toml:"host"`
 	Port    int    `toml:"port"`
+
+	RejectedMethods []string `toml:"rejected_methods"`
 }
 
 type RateLimitConfig struct {
@@ -64,8 +104,19 @@ type RateLimitConfig struct {
 	ErrorMessage     string                              `toml:"error_message"`
 	MethodOverrides  map[string]*RateLimitMethodOverride `toml:"method_overrides"`
 	IPHeaderOverride string                              `toml:"ip_header_override"`
+	// Mode "soft" queues requests over the limit until the next interval, up to QueueDepth
+	// requests per IP (default 10), instead of rejecting them right away
+	Mode       RateLimitMode `toml:"mode"`
+	QueueDepth int           `toml:"queue_depth"`
 }
 
+type RateLimitMode string
+
+const (
+	RateLimitModeHard RateLimitMode = "hard"
+	RateLimitModeSoft RateLimitMode = "soft"
+)
+
 type RateLimitMethodOverride struct {
 	Limit    int          `toml:"limit"`
 	Interval TOMLDuration `toml:"interval"`
@@ -92,6 +143,22 @@ type BackendOptions struct {
 	MaxDegradedLatencyThreshold TOMLDuration `toml:"max_degraded_latency_threshold"`
 	MaxLatencyThreshold         TOMLDuration `toml:"max_latency_threshold"`
 	MaxErrorRateThreshold       float64      `toml:"max_error_rate_threshold"`
+	// NonRetryableErrors and NonRetryableErrorCodes identify deterministic backend errors that aren't retried
+	NonRetryableErrors     []string `toml:"non_retryable_errors"`
+	NonRetryableErrorCodes []int    `toml:"non_retryable_error_codes"`
+	// MethodMaxResponseSizeBytes overrides MaxResponseSizeBytes for the given methods
+	MethodMaxResponseSizeBytes map[string]int64 `toml:"method_max_response_size_bytes"`
+	// UnhealthyAfter and HealthyAfter are how long unhealthy (healthy) readings must persist
+	// before a healthy (unhealthy) backend changes state, disabled by default
+	UnhealthyAfter TOMLDuration `toml:"unhealthy_after"`
+	HealthyAfter   TOMLDuration `toml:"healthy_after"`
+	// SlowRequestThreshold logs and counts forwarded requests slower than this, disabled by default
+	SlowRequestThreshold TOMLDuration `toml:"slow_request_threshold"`
+	// NormalizeJSONRPCVersion sets the jsonrpc field of backend responses to "2.0" when
+	// it is missing or different
+	NormalizeJSONRPCVersion bool `toml:"normalize_jsonrpc_version"`
+	// ValidateResponseIDs rejects backend responses whose IDs don't match the request IDs
+	ValidateResponseIDs bool `toml:"validate_response_ids"`
 }
 
 type BackendConfig struct {
@@ -107,9 +174,38 @@ type BackendConfig struct {
 	ClientKeyFile    string            `toml:"client_key_file"`
 	StripTrailingXFF bool              `toml:"strip_trailing_xff"`
 	Headers          map[string]string `toml:"headers"`
+	Zone             string            `toml:"zone"`
+
+	// TLSCertificatePins are SHA-256 fingerprints of the DER encoded backend certificate, or of a
+	// certificate in its chain, that the backend must present
+	TLSCertificatePins []string `toml:"tls_certificate_pins"`
+
+	// RequestIDHeader injects a request ID into requests sent to the backend, e.g. "X-Request-ID".
+	// PassthroughClientID reuses the value of that header when sent by the client.
+	RequestIDHeader     string `toml:"request_id_header"`
+	PassthroughClientID bool   `toml:"passthrough_client_id"`
+
+	// HealthCheckInterval enables an active health check of the backend, calling HealthCheckMethod
+	// (default eth_syncing) and expecting HealthCheckExpectedResult if set (default false for eth_syncing)
+	HealthCheckInterval       TOMLDuration `toml:"health_check_interval"`
+	HealthCheckMethod         string       `toml:"health_check_method"`
+	HealthCheckExpectedResult string       `toml:"health_check_expected_result"`
 
 	Weight int `toml:"weight"`
 
+	// Connection reuse of the backend's HTTP transport, the Go defaults are kept when unset.
+	// KeepAlive is the TCP keep-alive probe interval, negative to disable probes.
+	MaxIdleConnsPerHost int          `toml:"max_idle_conns_per_host"`
+	IdleConnTimeout     TOMLDuration `toml:"idle_conn_timeout"`
+	KeepAlive           TOMLDuration `toml:"keep_alive"`
+	DisableKeepAlives   bool         `toml:"disable_keep_alives"`
+
+	// MaxBatchSize splits batches sent to this backend into sub-batches of at most this many
+	// calls, sent BatchParallelism at a time. Unlike the backend group max_batch_size, it only
+	// applies to this backend and never rejects batches. Disabled if 0.
+	MaxBatchSize     int `toml:"max_batch_size"`
+	BatchParallelism int `toml:"batch_parallelism"`
+
 	ConsensusSkipPeerCountCheck bool   `toml:"consensus_skip_peer_count"`
 	ConsensusForcedCandidate    bool   `toml:"consensus_forced_candidate"`
 	ConsensusReceiptsTarget     string `toml:"consensus_receipts_target"`
@@ -140,6 +236,8 @@ func (b *BackendGroupConfig) ValidateRoutingStrategy(bgName string) bool {
 		return true
 	case FallbackRoutingStrategy:
 		return true
+	case LatencyWeightedRoutingStrategy:
+		return true
 	case "":
 		log.Info("Empty routing strategy provided for backend_group, using fallback strategy ", "name", bgName)
 		b.RoutingStrategy = FallbackRoutingStrategy
@@ -150,9 +248,20 @@ func (b *BackendGroupConfig) ValidateRoutingStrategy(bgName string) bool {
 }
 
 const (
-	ConsensusAwareRoutingStrategy RoutingStrategy = "consensus_aware"
-	MulticallRoutingStrategy      RoutingStrategy = "multicall"
-	FallbackRoutingStrategy       RoutingStrategy = "fallback"
+	ConsensusAwareRoutingStrategy  RoutingStrategy = "consensus_aware"
+	MulticallRoutingStrategy       RoutingStrategy = "multicall"
+	FallbackRoutingStrategy        RoutingStrategy = "fallback"
+	LatencyWeightedRoutingStrategy RoutingStrategy = "latency_weighted"
+)
+
+// OversizePolicy is how a backend group handles batches larger than its max_batch_size
+type OversizePolicy string
+
+const (
+	// OversizeReject fails the whole batch with ErrTooManyBatchRequests
+	OversizeReject OversizePolicy = "reject"
+	// OversizeSplit forwards the batch in chunks of at most max_batch_size calls
+	OversizeSplit OversizePolicy = "split"
 )
 
 type BackendGroupConfig struct {
@@ -164,6 +273,11 @@ type BackendGroupConfig struct {
 
 	MulticallRPCErrorCheck bool `toml:"multicall_rpc_error_check"`
 
+	// Draining lists backends of the group that receive no new requests, e.g. ahead of
+	// maintenance. A drained backend is drained in every group it belongs to. Applied again
+	// when the config is reloaded.
+	Draining []string `toml:"draining"`
+
 	/*
 		Deprecated: Use routing_strategy config to create a consensus_aware proxyd instance
 	*/
@@ -176,6 +290,17 @@ type BackendGroupConfig struct {
 	ConsensusMaxBlockLag        uint64       `toml:"consensus_max_block_lag"`
 	ConsensusMaxBlockRange      uint64       `toml:"consensus_max_block_range"`
 	ConsensusMinPeerCount       int          `toml:"consensus_min_peer_count"`
+	// MaxBlockDepth rejects requests for blocks older than the latest block minus this depth,
+	// for consensus aware groups of non-archive backends. Disabled if 0.
+	MaxBlockDepth uint64 `toml:"max_block_depth"`
+	// ConsensusQuorum is the fraction of candidates (0.5 to 1) that must agree on a block, default 1
+	ConsensusQuorum float64 `toml:"consensus_quorum"`
+	// ConsensusEvictionThreshold and ConsensusReadmissionThreshold are the number of consecutive
+	// polls a backend must fail (pass) before leaving (rejoining) the consensus group
+	ConsensusEvictionThreshold    int `toml:"consensus_eviction_threshold"`
+	ConsensusReadmissionThreshold int `toml:"consensus_readmission_threshold"`
+	// ConsensusLatencyWeighted load balances the consensus group like the latency_weighted routing strategy
+	ConsensusLatencyWeighted bool `toml:"consensus_latency_weighted"`
 
 	ConsensusHA                  bool         `toml:"consensus_ha"`
 	ConsensusHAHeartbeatInterval TOMLDuration `toml:"consensus_ha_heartbeat_interval"`
@@ -183,6 +308,72 @@ type BackendGroupConfig struct {
 	ConsensusHARedis             RedisConfig  `toml:"consensus_ha_redis"`
 
 	Fallbacks []string `toml:"fallbacks"`
+
+	ResponseValidation ResponseValidationConfig `toml:"response_validation"`
+
+	// ShadowBackend receives a copy of ShadowSampleRate (0 to 1, default 1) of the group's
+	// requests. Its responses are discarded and only compared to the served responses.
+	ShadowBackend       string  `toml:"shadow_backend"`
+	ShadowSampleRate    float64 `toml:"shadow_sample_rate"`
+	ShadowLogDivergence bool    `toml:"shadow_log_divergence"`
+
+	// ErrorNormalization rewrites known backend error messages to a canonical error
+	ErrorNormalization []ErrorNormalizationRule `toml:"error_normalization"`
+
+	// ExpectedChainID, when set, makes proxyd verify on startup that every backend
+	// in the group reports this chain id and refuse to start otherwise
+	ExpectedChainID     uint64       `toml:"expected_chain_id"`
+	ChainIDCheckTimeout TOMLDuration `toml:"chain_id_check_timeout"`
+
+	WSClientSideFiltering bool `toml:"ws_client_side_filtering"`
+
+	// FallbackGroup is tried when no backend in this group is able to serve the request
+	FallbackGroup string `toml:"fallback_group"`
+
+	// AccountsInterceptList are methods answered by proxyd on WS connections instead of being forwarded
+	AccountsInterceptList []string `toml:"accounts_intercept_list"`
+	// AccountsInterceptResponses overrides the JSON result returned for an intercepted method
+	AccountsInterceptResponses map[string]string `toml:"accounts_intercept_responses"`
+
+	// MaxBatchSize caps the number of calls of a batch routed to this group, handling larger
+	// batches according to OnOversize (reject or split, default reject). Split batches are
+	// forwarded like batches over a backend's own max_batch_size, honoring its batch_parallelism,
+	// and the smaller of the two limits applies. Disabled if 0.
+	MaxBatchSize int            `toml:"max_batch_size"`
+	OnOversize   OversizePolicy `toml:"on_oversize"`
+
+	// FailFastOnClientError returns a backend's 4xx JSON-RPC request error (parse error, invalid
+	// request or invalid params) to the client instead of retrying it and failing over to the other
+	// backends, which would return the same error. Auth, not found and size limit statuses still fail over.
+	FailFastOnClientError bool `toml:"fail_fast_on_client_error"`
+
+	// CanaryRequests are sent to every backend of the group each CanaryInterval. Backends whose
+	// responses diverge from the others are reported, e.g. a different hash for the same block.
+	CanaryInterval TOMLDuration          `toml:"canary_interval"`
+	CanaryRequests []CanaryRequestConfig `toml:"canary_requests"`
+}
+
+type CanaryRequestConfig struct {
+	Method string `toml:"method"`
+	// Params is a JSON array, default []
+	Params string `toml:"params"`
+}
+
+// ResponseValidationConfig enables structural validation of backend responses.
+// Validation is disabled when no mode is set.
+type ResponseValidationConfig struct {
+	Mode  ResponseValidationMode `toml:"mode"`
+	Rules []string               `toml:"rules"`
+}
+
+// ErrorNormalizationRule maps backend errors to a canonical code and message. An error matches
+// if its message contains Match (case-insensitive) and, when set, its code equals MatchCode.
+// A zero Code keeps the backend's error code.
+type ErrorNormalizationRule struct {
+	Match     string `toml:"match"`
+	MatchCode int    `toml:"match_code"`
+	Code      int    `toml:"code"`
+	Message   string `toml:"message"`
 }
 
 type BackendGroupsConfig map[string]*BackendGroupConfig
@@ -210,11 +401,14 @@ type Config struct {
 	Cache                 CacheConfig           `toml:"cache"`
 	Redis                 RedisConfig           `toml:"redis"`
 	Metrics               MetricsConfig         `toml:"metrics"`
+	Logging               LoggingConfig         `toml:"logging"`
+	AccessLog             AccessLogConfig       `toml:"access_log"`
 	RateLimit             RateLimitConfig       `toml:"rate_limit"`
 	BackendOptions        BackendOptions        `toml:"backend"`
 	Backends              BackendsConfig        `toml:"backends"`
 	BatchConfig           BatchConfig           `toml:"batch"`
 	Authentication        map[string]string     `toml:"authentication"`
+	ClientTiers           map[string]string     `toml:"client_tiers"`
 	BackendGroups         BackendGroupsConfig   `toml:"backend_groups"`
 	RPCMethodMappings     map[string]string     `toml:"rpc_method_mappings"`
 	WSMethodWhitelist     []string              `toml:"ws_method_whitelist"`
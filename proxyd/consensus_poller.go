@@ -3,6 +3,8 @@ package proxyd
 import (
 	"context"
 	"fmt"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -39,7 +41,21 @@ type ConsensusPoller struct {
 	maxUpdateThreshold time.Duration
 	maxBlockLag        uint64
 	maxBlockRange      uint64
+	maxBlockDepth      uint64
 	interval           time.Duration
+	quorum             float64
+
+	// membership tracks consecutive poll outcomes per backend, so that a backend is only evicted from
+	// (or readmitted to) the consensus group after evictionThreshold (readmissionThreshold) polls in a row
+	membership           map[*Backend]*consensusMembership
+	evictionThreshold    int
+	readmissionThreshold int
+}
+
+type consensusMembership struct {
+	member         bool
+	consecutiveIn  int
+	consecutiveOut int
 }
 
 type backendState struct {
@@ -254,12 +270,41 @@ func WithMaxBlockRange(maxBlockRange uint64) ConsensusOpt {
 	}
 }
 
+func WithMaxBlockDepth(maxBlockDepth uint64) ConsensusOpt {
+	return func(cp *ConsensusPoller) {
+		cp.maxBlockDepth = maxBlockDepth
+	}
+}
+
 func WithMinPeerCount(minPeerCount uint64) ConsensusOpt {
 	return func(cp *ConsensusPoller) {
 		cp.minPeerCount = minPeerCount
 	}
 }
 
+// WithQuorum sets the fraction of consensus candidates that must agree on a block
+func WithQuorum(quorum float64) ConsensusOpt {
+	return func(cp *ConsensusPoller) {
+		cp.quorum = quorum
+	}
+}
+
+// WithEvictionThreshold sets the number of consecutive polls a member must fail to qualify as a
+// candidate before it is removed from the consensus group
+func WithEvictionThreshold(polls int) ConsensusOpt {
+	return func(cp *ConsensusPoller) {
+		cp.evictionThreshold = polls
+	}
+}
+
+// WithReadmissionThreshold sets the number of consecutive polls a backend must qualify as a
+// candidate before it is added back to the consensus group
+func WithReadmissionThreshold(polls int) ConsensusOpt {
+	return func(cp *ConsensusPoller) {
+		cp.readmissionThreshold = polls
+	}
+}
+
 func WithPollerInterval(interval time.Duration) ConsensusOpt {
 	return func(cp *ConsensusPoller) {
 		cp.interval = interval
@@ -282,6 +327,11 @@ func NewConsensusPoller(bg *BackendGroup, opts ...ConsensusOpt) *ConsensusPoller
 		maxBlockLag:        8, // 8*12 seconds = 96 seconds ~ 1.6 minutes
 		minPeerCount:       3,
 		interval:           DefaultPollerInterval,
+		quorum:             1,
+
+		membership:           make(map[*Backend]*consensusMembership),
+		evictionThreshold:    1,
+		readmissionThreshold: 1,
 	}
 
 	for _, opt := range opts {
@@ -439,73 +489,91 @@ func (cp *ConsensusPoller) UpdateBackendGroupConsensus(ctx context.Context) {
 	currentConsensusBlockNumber := cp.GetLatestBlockNumber()
 
 	// get the candidates for the consensus group
-	candidates := cp.getConsensusCandidates()
-
-	// update the lowest latest block number and hash
-	//        the lowest safe block number
-	//        the lowest finalized block number
-	var lowestLatestBlock hexutil.Uint64
-	var lowestLatestBlockHash string
-	var lowestFinalizedBlock hexutil.Uint64
-	var lowestSafeBlock hexutil.Uint64
+	candidates, retained := cp.applyMembershipHysteresis(cp.getConsensusCandidates())
+
+	// update the quorum latest block number
+	//        the quorum safe block number
+	//        the quorum finalized block number
+	// i.e. the highest blocks reached by a quorum of the candidates, which
+	// are the lowest blocks when the whole group has to agree (the default)
+	quorum := cp.quorumSize(len(candidates))
+	latestBlocks := make([]hexutil.Uint64, 0, len(candidates))
+	safeBlocks := make([]hexutil.Uint64, 0, len(candidates))
+	finalizedBlocks := make([]hexutil.Uint64, 0, len(candidates))
 	for _, bs := range candidates {
-		if lowestLatestBlock == 0 || bs.latestBlockNumber < lowestLatestBlock {
-			lowestLatestBlock = bs.latestBlockNumber
-			lowestLatestBlockHash = bs.latestBlockHash
-		}
-		if lowestFinalizedBlock == 0 || bs.finalizedBlockNumber < lowestFinalizedBlock {
-			lowestFinalizedBlock = bs.finalizedBlockNumber
-		}
-		if lowestSafeBlock == 0 || bs.safeBlockNumber < lowestSafeBlock {
-			lowestSafeBlock = bs.safeBlockNumber
-		}
+		latestBlocks = append(latestBlocks, bs.latestBlockNumber)
+		safeBlocks = append(safeBlocks, bs.safeBlockNumber)
+		finalizedBlocks = append(finalizedBlocks, bs.finalizedBlockNumber)
 	}
+	quorumLatestBlock := quorumBlock(latestBlocks, quorum)
+	quorumSafeBlock := quorumBlock(safeBlocks, quorum)
+	quorumFinalizedBlock := quorumBlock(finalizedBlocks, quorum)
 
 	// find the proposed block among the candidates
-	// the proposed block needs have the same hash in the entire consensus group
-	proposedBlock := lowestLatestBlock
-	proposedBlockHash := lowestLatestBlockHash
+	// the proposed block needs have the same hash in a quorum of the consensus group
+	proposedBlock := quorumLatestBlock
+	proposedBlockHash := ""
 	hasConsensus := false
 	broken := false
+	disagreed := make(map[*Backend]bool)
+	// candidates that fail to return a block don't vote and are left out of the group
+	unresponsive := make(map[*Backend]bool)
 
-	if lowestLatestBlock > currentConsensusBlockNumber {
-		log.Debug("validating consensus on block", "lowestLatestBlock", lowestLatestBlock)
+	if quorumLatestBlock > currentConsensusBlockNumber {
+		log.Debug("validating consensus on block", "quorumLatestBlock", quorumLatestBlock)
 	}
 
-	// if there is a block to propose, check if it is the same in all backends
+	// if there is a block to propose, check if it is the same in a quorum of backends
 	if proposedBlock > 0 {
 		for !hasConsensus {
-			allAgreed := true
+			blocks := make(map[*Backend]consensusBlock, len(candidates))
 			for be := range candidates {
+				if unresponsive[be] {
+					continue
+				}
 				actualBlockNumber, actualBlockHash, err := cp.fetchBlock(ctx, be, proposedBlock.String())
 				if err != nil {
 					log.Warn("error updating backend", "name", be.Name, "err", err)
+					unresponsive[be] = true
 					continue
 				}
-				if proposedBlockHash == "" {
-					proposedBlockHash = actualBlockHash
+				blocks[be] = consensusBlock{number: actualBlockNumber, hash: actualBlockHash}
+			}
+			if len(blocks) == 0 {
+				log.Warn("no candidate returned the proposed block, keeping the current consensus",
+					"proposedBlock", proposedBlock)
+				proposedBlock = currentConsensusBlockNumber
+				break
+			}
+
+			var votes int
+			proposedBlockHash, votes = voteBlockHash(proposedBlock, blocks)
+			disagreed = make(map[*Backend]bool)
+			brokenByCandidate := false
+			for be, block := range blocks {
+				if block.number == proposedBlock && block.hash == proposedBlockHash && proposedBlockHash != "" {
+					continue
 				}
-				blocksDontMatch := (actualBlockNumber != proposedBlock) || (actualBlockHash != proposedBlockHash)
-				if blocksDontMatch {
-					if currentConsensusBlockNumber >= actualBlockNumber {
-						log.Warn("backend broke consensus",
-							"name", be.Name,
-							"actualBlockNumber", actualBlockNumber,
-							"actualBlockHash", actualBlockHash,
-							"proposedBlock", proposedBlock,
-							"proposedBlockHash", proposedBlockHash)
-						broken = true
-					}
-					allAgreed = false
-					break
+				if currentConsensusBlockNumber >= block.number {
+					log.Warn("backend broke consensus",
+						"name", be.Name,
+						"actualBlockNumber", block.number,
+						"actualBlockHash", block.hash,
+						"proposedBlock", proposedBlock,
+						"proposedBlockHash", proposedBlockHash)
+					brokenByCandidate = true
 				}
+				disagreed[be] = true
 			}
-			if allAgreed {
+			// the quorum is taken among the candidates that returned the block
+			if proposedBlockHash != "" && votes >= cp.quorumSize(len(blocks)) {
 				hasConsensus = true
+			} else if proposedBlock == 0 {
+				break
 			} else {
+				broken = broken || brokenByCandidate
 				// walk one block behind and try again
 				proposedBlock -= 1
-				proposedBlockHash = ""
 				log.Debug("no consensus, now trying", "block:", proposedBlock)
 			}
 		}
@@ -524,8 +592,8 @@ func (cp *ConsensusPoller) UpdateBackendGroupConsensus(ctx context.Context) {
 
 	// update tracker
 	cp.tracker.SetLatestBlockNumber(proposedBlock)
-	cp.tracker.SetSafeBlockNumber(lowestSafeBlock)
-	cp.tracker.SetFinalizedBlockNumber(lowestFinalizedBlock)
+	cp.tracker.SetSafeBlockNumber(quorumSafeBlock)
+	cp.tracker.SetFinalizedBlockNumber(quorumFinalizedBlock)
 
 	// update consensus group
 	group := make([]*Backend, 0, len(candidates))
@@ -533,7 +601,7 @@ func (cp *ConsensusPoller) UpdateBackendGroupConsensus(ctx context.Context) {
 	filteredBackendsNames := make([]string, 0, len(cp.backendGroup.Backends))
 	for _, be := range cp.backendGroup.Backends {
 		_, exist := candidates[be]
-		if exist {
+		if (exist && !disagreed[be] && !unresponsive[be]) || retained[be] {
 			group = append(group, be)
 			consensusBackendsNames = append(consensusBackendsNames, be.Name)
 		} else {
@@ -546,8 +614,8 @@ func (cp *ConsensusPoller) UpdateBackendGroupConsensus(ctx context.Context) {
 	cp.consensusGroupMux.Unlock()
 
 	RecordGroupConsensusLatestBlock(cp.backendGroup, proposedBlock)
-	RecordGroupConsensusSafeBlock(cp.backendGroup, lowestSafeBlock)
-	RecordGroupConsensusFinalizedBlock(cp.backendGroup, lowestFinalizedBlock)
+	RecordGroupConsensusSafeBlock(cp.backendGroup, quorumSafeBlock)
+	RecordGroupConsensusFinalizedBlock(cp.backendGroup, quorumFinalizedBlock)
 
 	RecordGroupConsensusCount(cp.backendGroup, len(group))
 	RecordGroupConsensusFilteredCount(cp.backendGroup, len(filteredBackendsNames))
@@ -559,6 +627,101 @@ func (cp *ConsensusPoller) UpdateBackendGroupConsensus(ctx context.Context) {
 		"filteredBackends", strings.Join(filteredBackendsNames, ", "))
 }
 
+// consensusBlock is a block returned by a candidate while looking for consensus
+type consensusBlock struct {
+	number hexutil.Uint64
+	hash   string
+}
+
+// voteBlockHash returns the hash of the given block returned by the most candidates, and their
+// number. No hash is returned when the block isn't returned by any candidate, or when several
+// hashes are tied, since the candidates don't agree on it.
+func voteBlockHash(number hexutil.Uint64, blocks map[*Backend]consensusBlock) (string, int) {
+	votes := make(map[string]int)
+	for _, block := range blocks {
+		if block.number == number {
+			votes[block.hash]++
+		}
+	}
+	var hash string
+	var most int
+	tied := false
+	for h, n := range votes {
+		switch {
+		case n > most:
+			hash, most, tied = h, n, false
+		case n == most:
+			tied = true
+		}
+	}
+	if tied {
+		return "", 0
+	}
+	return hash, most
+}
+
+// quorumSize returns the number of candidates that must agree on a block
+func (cp *ConsensusPoller) quorumSize(candidates int) int {
+	// tolerate floating point error, e.g. 0.7 * 10 = 7.000000000000001
+	return max(int(math.Ceil(cp.quorum*float64(candidates)-1e-9)), 1)
+}
+
+// quorumBlock returns the highest block number reached by at least quorum of the given blocks
+func quorumBlock(blocks []hexutil.Uint64, quorum int) hexutil.Uint64 {
+	if len(blocks) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(blocks)
+	slices.Sort(sorted)
+	return sorted[len(sorted)-min(quorum, len(sorted))]
+}
+
+// applyMembershipHysteresis filters the candidates of this poll down to the backends admitted to the
+// consensus group, and returns the members that are no longer candidates but are kept in the group
+// until they fail enough consecutive polls. Retained members don't take part in proposing a block.
+func (cp *ConsensusPoller) applyMembershipHysteresis(candidates map[*Backend]*backendState) (map[*Backend]*backendState, map[*Backend]bool) {
+	admitted := make(map[*Backend]*backendState, len(candidates))
+	retained := make(map[*Backend]bool)
+
+	for _, be := range cp.backendGroup.Backends {
+		bs, isCandidate := candidates[be]
+		m, ok := cp.membership[be]
+		if !ok {
+			// backends seen for the first time join right away
+			m = &consensusMembership{member: isCandidate}
+			cp.membership[be] = m
+		}
+		if isCandidate {
+			m.consecutiveIn++
+			m.consecutiveOut = 0
+		} else {
+			m.consecutiveOut++
+			m.consecutiveIn = 0
+		}
+
+		switch {
+		case isCandidate && (m.member || be.forcedCandidate || m.consecutiveIn >= cp.readmissionThreshold):
+			m.member = true
+			admitted[be] = bs
+		case isCandidate:
+			log.Debug("backend not yet readmitted to consensus group",
+				"backend_name", be.Name,
+				"consecutive_polls", m.consecutiveIn,
+				"readmission_threshold", cp.readmissionThreshold)
+		case m.member && m.consecutiveOut < cp.evictionThreshold && !cp.IsBanned(be):
+			log.Debug("retaining backend in consensus group",
+				"backend_name", be.Name,
+				"consecutive_polls", m.consecutiveOut,
+				"eviction_threshold", cp.evictionThreshold)
+			retained[be] = true
+		default:
+			m.member = false
+		}
+	}
+
+	return admitted, retained
+}
+
 // IsBanned checks if a specific backend is banned
 func (cp *ConsensusPoller) IsBanned(be *Backend) bool {
 	bs := cp.backendState[be]
@@ -605,6 +768,7 @@ func (cp *ConsensusPoller) Reset() {
 	for _, be := range cp.backendGroup.Backends {
 		cp.backendState[be] = &backendState{}
 	}
+	cp.membership = make(map[*Backend]*consensusMembership)
 }
 
 // fetchBlock is a convenient wrapper to make a request to get a block directly from the backend
@@ -731,6 +895,7 @@ func (cp *ConsensusPoller) getConsensusCandidates() map[*Backend]*backendState {
 // and create a copy of current their state
 //
 // a candidate is a serving node within the following conditions:
+//   - not drained
 //   - not banned
 //   - healthy (network latency and error rate)
 //   - with minimum peer count
@@ -743,6 +908,10 @@ func (cp *ConsensusPoller) FilterCandidates(backends []*Backend) map[*Backend]*b
 
 	for _, be := range backends {
 
+		// drained backends take no new traffic, but aren't banned so they rejoin once undrained
+		if be.IsDraining() {
+			continue
+		}
 		bs := cp.GetBackendState(be)
 		if be.forcedCandidate {
 			candidates[be] = bs
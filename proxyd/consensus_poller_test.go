@@ -0,0 +1,170 @@
+package proxyd
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsensusMembershipHysteresis(t *testing.T) {
+	node1 := NewBackend("node1", "http://node1", "", nil)
+	node2 := NewBackend("node2", "http://node2", "", nil)
+	bg := &BackendGroup{
+		Name:     "node",
+		Backends: []*Backend{node1, node2},
+	}
+
+	cp := NewConsensusPoller(bg,
+		WithAsyncHandler(NewNoopAsyncHandler()),
+		WithEvictionThreshold(2),
+		WithReadmissionThreshold(2),
+	)
+
+	both := func() map[*Backend]*backendState {
+		return map[*Backend]*backendState{node1: {}, node2: {}}
+	}
+	onlyNode1 := func() map[*Backend]*backendState {
+		return map[*Backend]*backendState{node1: {}}
+	}
+
+	// backends join right away on the first poll
+	admitted, retained := cp.applyMembershipHysteresis(both())
+	require.Len(t, admitted, 2)
+	require.Empty(t, retained)
+
+	// a single poll blip keeps node2 in the group, without proposing blocks
+	admitted, retained = cp.applyMembershipHysteresis(onlyNode1())
+	require.Len(t, admitted, 1)
+	require.Contains(t, admitted, node1)
+	require.True(t, retained[node2])
+
+	// recovering right after the blip doesn't need readmission
+	admitted, retained = cp.applyMembershipHysteresis(both())
+	require.Len(t, admitted, 2)
+	require.Empty(t, retained)
+
+	// node2 is evicted after two consecutive failed polls
+	cp.applyMembershipHysteresis(onlyNode1())
+	admitted, retained = cp.applyMembershipHysteresis(onlyNode1())
+	require.Len(t, admitted, 1)
+	require.Empty(t, retained)
+
+	// and is readmitted after two consecutive good polls
+	admitted, _ = cp.applyMembershipHysteresis(both())
+	require.NotContains(t, admitted, node2)
+	admitted, _ = cp.applyMembershipHysteresis(both())
+	require.Contains(t, admitted, node2)
+}
+
+func TestConsensusMembershipDefaultThresholds(t *testing.T) {
+	node1 := NewBackend("node1", "http://node1", "", nil)
+	node2 := NewBackend("node2", "http://node2", "", nil)
+	bg := &BackendGroup{
+		Name:     "node",
+		Backends: []*Backend{node1, node2},
+	}
+
+	cp := NewConsensusPoller(bg, WithAsyncHandler(NewNoopAsyncHandler()))
+
+	cp.applyMembershipHysteresis(map[*Backend]*backendState{node1: {}, node2: {}})
+
+	// with the default thresholds a single failed poll evicts the backend
+	admitted, retained := cp.applyMembershipHysteresis(map[*Backend]*backendState{node1: {}})
+	require.NotContains(t, admitted, node2)
+	require.Empty(t, retained)
+
+	// and a single good poll readmits it
+	admitted, _ = cp.applyMembershipHysteresis(map[*Backend]*backendState{node1: {}, node2: {}})
+	require.Contains(t, admitted, node2)
+}
+
+func TestConsensusQuorumBlock(t *testing.T) {
+	// five candidates, two of them lagging behind
+	blocks := []hexutil.Uint64{0x105, 0x101, 0x105, 0x104, 0x100}
+
+	tests := []struct {
+		name     string
+		quorum   float64
+		expected hexutil.Uint64
+	}{
+		{"unanimous", 1, 0x100},
+		{"three fifths", 0.6, 0x104},
+		{"bare majority", 0.5, 0x104},
+		{"unanimous minus one", 0.8, 0x101},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp := &ConsensusPoller{quorum: tt.quorum}
+			require.Equal(t, tt.expected, quorumBlock(blocks, cp.quorumSize(len(blocks))))
+		})
+	}
+}
+
+func TestConsensusQuorumSize(t *testing.T) {
+	tests := []struct {
+		quorum     float64
+		candidates int
+		expected   int
+	}{
+		{1, 3, 3},
+		{0.5, 3, 2},
+		{0.5, 4, 2},
+		{0.67, 3, 3},
+		{0.66, 3, 2},
+		{0.7, 10, 7},
+		{0.5, 1, 1},
+		{0.5, 0, 1},
+	}
+	for _, tt := range tests {
+		cp := &ConsensusPoller{quorum: tt.quorum}
+		require.Equal(t, tt.expected, cp.quorumSize(tt.candidates), "quorum %v of %d", tt.quorum, tt.candidates)
+	}
+}
+
+func TestConsensusQuorumExcludesBlock(t *testing.T) {
+	// a bare majority accepts block 0x102, which only 2 of 3 backends have reached
+	blocks := []hexutil.Uint64{0x102, 0x102, 0x100}
+
+	majority := &ConsensusPoller{quorum: 0.5}
+	require.Equal(t, hexutil.Uint64(0x102), quorumBlock(blocks, majority.quorumSize(len(blocks))))
+
+	// raising the quorum excludes it
+	strong := &ConsensusPoller{quorum: 0.9}
+	require.Equal(t, hexutil.Uint64(0x100), quorumBlock(blocks, strong.quorumSize(len(blocks))))
+
+	require.Equal(t, hexutil.Uint64(0), quorumBlock(nil, 1))
+}
+
+func TestVoteBlockHash(t *testing.T) {
+	a, b, c, d := &Backend{}, &Backend{}, &Backend{}, &Backend{}
+
+	// a forked candidate doesn't decide the hash, whichever candidate comes first
+	hash, votes := voteBlockHash(0x102, map[*Backend]consensusBlock{
+		a: {0x102, "hash_0x102"},
+		b: {0x102, "forked_0x102"},
+		c: {0x102, "hash_0x102"},
+	})
+	require.Equal(t, "hash_0x102", hash)
+	require.Equal(t, 2, votes)
+	cp := &ConsensusPoller{quorum: 0.5}
+	require.GreaterOrEqual(t, votes, cp.quorumSize(3))
+
+	// candidates returning another block don't vote
+	hash, votes = voteBlockHash(0x102, map[*Backend]consensusBlock{
+		a: {0x102, "hash_0x102"},
+		b: {0x101, "hash_0x101"},
+	})
+	require.Equal(t, "hash_0x102", hash)
+	require.Equal(t, 1, votes)
+
+	// a tie has no hash
+	hash, votes = voteBlockHash(0x102, map[*Backend]consensusBlock{
+		a: {0x102, "hash_0x102"},
+		b: {0x102, "forked_0x102"},
+		c: {0x102, "hash_0x102"},
+		d: {0x102, "forked_0x102"},
+	})
+	require.Equal(t, "", hash)
+	require.Equal(t, 0, votes)
+}
@@ -0,0 +1,78 @@
+package proxyd
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrorNormalizer rewrites backend-specific RPC errors to canonical errors, so that clients see the
+// same error regardless of the backend that served the request. The backend's original message is
+// kept in the error data, unless the error already carries data such as revert data.
+type ErrorNormalizer struct {
+	rules []ErrorNormalizationRule
+}
+
+func NewErrorNormalizer(rules []ErrorNormalizationRule) (*ErrorNormalizer, error) {
+	normalized := make([]ErrorNormalizationRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Match == "" && rule.MatchCode == 0 {
+			return nil, errors.New("error normalization rule must set match or match_code")
+		}
+		if rule.Message == "" {
+			return nil, errors.New("error normalization rule must set message")
+		}
+		rule.Match = strings.ToLower(rule.Match)
+		normalized = append(normalized, rule)
+	}
+	return &ErrorNormalizer{rules: normalized}, nil
+}
+
+// Normalize replaces the errors of the given responses that match a rule. The first matching rule wins.
+func (n *ErrorNormalizer) Normalize(ctx context.Context, res []*RPCRes) {
+	for _, r := range res {
+		if !r.IsError() {
+			continue
+		}
+		rule, ok := n.match(r.Error)
+		if !ok {
+			continue
+		}
+
+		code := rule.Code
+		if code == 0 {
+			code = r.Error.Code
+		}
+		GetLogger(ctx).Debug("normalized backend error",
+			"req_id", GetReqID(ctx),
+			"original_code", r.Error.Code,
+			"original_msg", r.Error.Message,
+			"code", code,
+			"msg", rule.Message,
+		)
+		data := r.Error.Data
+		if data == "" {
+			data = r.Error.Message
+		}
+		r.Error = &RPCErr{
+			Code:          code,
+			Message:       rule.Message,
+			Data:          data,
+			HTTPErrorCode: r.Error.HTTPErrorCode,
+		}
+	}
+}
+
+func (n *ErrorNormalizer) match(rpcErr *RPCErr) (ErrorNormalizationRule, bool) {
+	msg := strings.ToLower(rpcErr.Message)
+	for _, rule := range n.rules {
+		if rule.MatchCode != 0 && rule.MatchCode != rpcErr.Code {
+			continue
+		}
+		if rule.Match != "" && !strings.Contains(msg, rule.Match) {
+			continue
+		}
+		return rule, true
+	}
+	return ErrorNormalizationRule{}, false
+}
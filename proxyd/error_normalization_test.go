@@ -0,0 +1,89 @@
+package proxyd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorNormalizer(t *testing.T) {
+	normalizer, err := NewErrorNormalizer([]ErrorNormalizationRule{
+		{Match: "nonce too low", Code: -32000, Message: "nonce too low"},
+		{Match: "header not found", Message: "block not found"},
+		{Match: "unknown block", Message: "block not found"},
+		{MatchCode: -32005, Code: -32005, Message: "limit exceeded"},
+		{MatchCode: 3, Message: "execution reverted"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		in       *RPCErr
+		expected *RPCErr
+	}{
+		{
+			name:     "geth nonce error",
+			in:       &RPCErr{Code: -32000, Message: "nonce too low: next nonce 5, tx nonce 4"},
+			expected: &RPCErr{Code: -32000, Message: "nonce too low", Data: "nonce too low: next nonce 5, tx nonce 4"},
+		},
+		{
+			name:     "nonce error with different code and casing",
+			in:       &RPCErr{Code: -32003, Message: "Nonce too low"},
+			expected: &RPCErr{Code: -32000, Message: "nonce too low", Data: "Nonce too low"},
+		},
+		{
+			name:     "geth missing block keeps code",
+			in:       &RPCErr{Code: -32000, Message: "header not found"},
+			expected: &RPCErr{Code: -32000, Message: "block not found", Data: "header not found"},
+		},
+		{
+			name:     "erigon missing block keeps code",
+			in:       &RPCErr{Code: -32602, Message: "unknown block number"},
+			expected: &RPCErr{Code: -32602, Message: "block not found", Data: "unknown block number"},
+		},
+		{
+			name:     "match by code",
+			in:       &RPCErr{Code: -32005, Message: "daily request count exceeded", HTTPErrorCode: 429},
+			expected: &RPCErr{Code: -32005, Message: "limit exceeded", Data: "daily request count exceeded", HTTPErrorCode: 429},
+		},
+		{
+			name:     "revert data is kept",
+			in:       &RPCErr{Code: 3, Message: "execution reverted: insufficient balance", Data: "0x08c379a0"},
+			expected: &RPCErr{Code: 3, Message: "execution reverted", Data: "0x08c379a0"},
+		},
+		{
+			name:     "unknown error is unchanged",
+			in:       &RPCErr{Code: -32000, Message: "execution reverted"},
+			expected: &RPCErr{Code: -32000, Message: "execution reverted"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := []*RPCRes{{JSONRPC: JSONRPCVersion, Error: tt.in}}
+			normalizer.Normalize(context.Background(), res)
+			require.Equal(t, tt.expected, res[0].Error)
+		})
+	}
+}
+
+func TestErrorNormalizerSkipsResults(t *testing.T) {
+	normalizer, err := NewErrorNormalizer([]ErrorNormalizationRule{
+		{Match: "header not found", Message: "block not found"},
+	})
+	require.NoError(t, err)
+
+	res := []*RPCRes{{JSONRPC: JSONRPCVersion, Result: "0x1"}}
+	normalizer.Normalize(context.Background(), res)
+	require.Nil(t, res[0].Error)
+	require.Equal(t, "0x1", res[0].Result)
+}
+
+func TestNewErrorNormalizerInvalidRules(t *testing.T) {
+	_, err := NewErrorNormalizer([]ErrorNormalizationRule{{Message: "block not found"}})
+	require.Error(t, err)
+
+	_, err = NewErrorNormalizer([]ErrorNormalizationRule{{Match: "header not found"}})
+	require.Error(t, err)
+}
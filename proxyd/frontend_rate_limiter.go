@@ -162,3 +162,82 @@ func (r *FallbackRateLimiter) Take(ctx context.Context, key string) (bool, error
 		return ok, err
 	}
 }
+
+const DefaultSoftRateLimitQueueDepth = 10
+
+// SoftFrontendRateLimiter wraps a rate limiter so that requests over the limit wait for the next
+// rate limit interval instead of being rejected right away. At most maxDepth requests per key
+// wait at a time; requests beyond that are rejected as with the wrapped limiter. A waiting request
+// gives up when its context is done, e.g. when the client disconnects.
+type SoftFrontendRateLimiter struct {
+	lim      FrontendRateLimiter
+	dur      time.Duration
+	maxDepth int
+
+	queues map[string]int
+	mtx    sync.Mutex
+}
+
+func NewSoftFrontendRateLimiter(lim FrontendRateLimiter, dur time.Duration, maxDepth int) FrontendRateLimiter {
+	if maxDepth <= 0 {
+		maxDepth = DefaultSoftRateLimitQueueDepth
+	}
+	return &SoftFrontendRateLimiter{
+		lim:      lim,
+		dur:      dur,
+		maxDepth: maxDepth,
+		queues:   make(map[string]int),
+	}
+}
+
+func (s *SoftFrontendRateLimiter) Take(ctx context.Context, key string) (bool, error) {
+	ok, err := s.lim.Take(ctx, key)
+	if ok || err != nil {
+		return ok, err
+	}
+
+	auth := GetAuthCtx(ctx)
+	if !s.enqueue(key, auth) {
+		return false, nil
+	}
+	defer s.dequeue(key, auth)
+
+	for {
+		// limits are reset when the truncated timestamp changes, so wait for the next interval
+		wait := time.Until(time.Now().Truncate(s.dur).Add(s.dur))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false, nil
+		case <-timer.C:
+		}
+
+		ok, err := s.lim.Take(ctx, key)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+}
+
+func (s *SoftFrontendRateLimiter) enqueue(key string, auth string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.queues[key] >= s.maxDepth {
+		return false
+	}
+	s.queues[key]++
+	softRateLimitQueuedTotal.WithLabelValues(auth).Inc()
+	softRateLimitQueueDepth.WithLabelValues(auth).Inc()
+	return true
+}
+
+func (s *SoftFrontendRateLimiter) dequeue(key string, auth string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.queues[key]--
+	if s.queues[key] == 0 {
+		delete(s.queues, key)
+	}
+	softRateLimitQueueDepth.WithLabelValues(auth).Dec()
+}
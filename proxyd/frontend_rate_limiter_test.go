@@ -3,6 +3,8 @@ package proxyd
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -84,3 +86,111 @@ func TestFallbackRateLimiter(t *testing.T) {
 		require.False(t, ok)
 	}
 }
+
+func TestSoftFrontendRateLimiter(t *testing.T) {
+	dur := 500 * time.Millisecond
+
+	t.Run("queued request passes in the next interval", func(t *testing.T) {
+		frl := NewSoftFrontendRateLimiter(NewMemoryFrontendRateLimit(dur, 1), dur, 1)
+		ctx := context.Background()
+
+		ok, err := frl.Take(ctx, "foo")
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = frl.Take(ctx, "foo")
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("full queue rejects", func(t *testing.T) {
+		frl := NewSoftFrontendRateLimiter(NewMemoryFrontendRateLimit(dur, 1), dur, 1).(*SoftFrontendRateLimiter)
+		ctx := context.Background()
+		// start at the beginning of an interval so the queued request doesn't pass early
+		time.Sleep(time.Until(time.Now().Truncate(dur).Add(dur)))
+
+		ok, err := frl.Take(ctx, "foo")
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		done := make(chan bool)
+		go func() {
+			ok, _ := frl.Take(ctx, "foo")
+			done <- ok
+		}()
+		require.Eventually(t, func() bool {
+			frl.mtx.Lock()
+			defer frl.mtx.Unlock()
+			return frl.queues["foo"] == 1
+		}, time.Second, time.Millisecond)
+
+		// both the queue and the limit of this key are full
+		ok, err = frl.Take(ctx, "foo")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// other keys have their own queue
+		ok, err = frl.Take(ctx, "bar")
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.True(t, <-done)
+	})
+
+	t.Run("cancelled request leaves the queue", func(t *testing.T) {
+		frl := NewSoftFrontendRateLimiter(NewMemoryFrontendRateLimit(time.Hour, 1), time.Hour, 1).(*SoftFrontendRateLimiter)
+
+		ok, err := frl.Take(context.Background(), "foo")
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		ok, err = frl.Take(ctx, "foo")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		frl.mtx.Lock()
+		defer frl.mtx.Unlock()
+		require.Empty(t, frl.queues)
+	})
+}
+
+func BenchmarkFrontendRateLimiterBurst(b *testing.B) {
+	dur := 10 * time.Millisecond
+	limit := 10
+	// bursts marginally exceeding the limit
+	burst := 12
+
+	modes := []struct {
+		name string
+		frl  func() FrontendRateLimiter
+	}{
+		{"hard", func() FrontendRateLimiter { return NewMemoryFrontendRateLimit(dur, limit) }},
+		{"soft", func() FrontendRateLimiter {
+			return NewSoftFrontendRateLimiter(NewMemoryFrontendRateLimit(dur, limit), dur, DefaultSoftRateLimitQueueDepth)
+		}},
+	}
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			frl := mode.frl()
+			ctx := context.Background()
+			var accepted atomic.Int64
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var wg sync.WaitGroup
+				for j := 0; j < burst; j++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						if ok, _ := frl.Take(ctx, "foo"); ok {
+							accepted.Add(1)
+						}
+					}()
+				}
+				wg.Wait()
+			}
+			b.ReportMetric(float64(accepted.Load())/float64(b.N*burst), "accepted/op")
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package proxyd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const DefaultHealthCheckMethod = "eth_syncing"
+
+// healthCheck actively polls a backend with a configured method, marking the backend
+// unhealthy while the call fails or returns an unexpected result
+type healthCheck struct {
+	method   string
+	expected interface{}
+	interval time.Duration
+
+	mu      sync.Mutex
+	failing bool
+	cancel  context.CancelFunc
+}
+
+// WithHealthCheck polls the backend with method every interval. The backend is unhealthy while
+// the call errors, or returns a result that isn't equal to expectedResult when it is set. An empty
+// method defaults to eth_syncing, which by default is expected to report the backend as not syncing.
+func WithHealthCheck(method string, expectedResult json.RawMessage, interval time.Duration) BackendOpt {
+	return func(b *Backend) {
+		if method == "" {
+			method = DefaultHealthCheckMethod
+			if expectedResult == nil {
+				expectedResult = json.RawMessage("false")
+			}
+		}
+		var expected interface{}
+		if expectedResult != nil {
+			if err := json.Unmarshal(expectedResult, &expected); err != nil {
+				log.Warn("invalid health check expected result, accepting any result",
+					"name", b.Name, "expected", string(expectedResult), "err", err)
+			}
+		}
+		b.healthCheck = &healthCheck{
+			method:   method,
+			expected: expected,
+			interval: interval,
+		}
+	}
+}
+
+// StartHealthCheck starts polling the backend in the background, if a health check is configured
+// and the backend isn't drained
+func (b *Backend) StartHealthCheck() {
+	hc := b.healthCheck
+	if hc == nil || b.IsDraining() {
+		return
+	}
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	if hc.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	hc.cancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(hc.interval)
+		defer ticker.Stop()
+		for {
+			b.runHealthCheck(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// StopHealthCheck stops polling the backend
+func (b *Backend) StopHealthCheck() {
+	hc := b.healthCheck
+	if hc == nil {
+		return
+	}
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	if hc.cancel != nil {
+		hc.cancel()
+		hc.cancel = nil
+	}
+}
+
+func (b *Backend) runHealthCheck(ctx context.Context) {
+	hc := b.healthCheck
+	ctx, cancel := context.WithTimeout(ctx, hc.interval)
+	defer cancel()
+
+	err := b.checkHealth(ctx)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+
+	hc.mu.Lock()
+	wasFailing := hc.failing
+	hc.failing = err != nil
+	hc.mu.Unlock()
+
+	if err != nil && !wasFailing {
+		log.Warn("backend health check failed", "name", b.Name, "method", hc.method, "err", err)
+	} else if err == nil && wasFailing {
+		log.Info("backend health check recovered", "name", b.Name, "method", hc.method)
+	}
+	RecordBackendHealthCheck(b, err == nil, time.Now())
+}
+
+func (b *Backend) checkHealth(ctx context.Context) error {
+	hc := b.healthCheck
+	var res RPCRes
+	if err := b.ForwardRPC(ctx, &res, "1", hc.method); err != nil {
+		return err
+	}
+	if hc.expected != nil && !reflect.DeepEqual(res.Result, hc.expected) {
+		return fmt.Errorf("unexpected %s result: %v", hc.method, res.Result)
+	}
+	return nil
+}
+
+// isHealthCheckFailing returns whether the last active health check failed
+func (b *Backend) isHealthCheckFailing() bool {
+	hc := b.healthCheck
+	if hc == nil {
+		return false
+	}
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	return hc.failing
+}
@@ -0,0 +1,74 @@
+package proxyd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+)
+
+func TestHealthCheck(t *testing.T) {
+	var syncing atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","result":%t,"id":1}`, syncing.Load())
+	}))
+	defer srv.Close()
+
+	b := NewBackend("health-check", srv.URL, "", semaphore.NewWeighted(10),
+		WithHealthCheck("", nil, 10*time.Millisecond),
+		WithStrippedTrailingXFF(),
+	)
+	b.StartHealthCheck()
+	defer b.StopHealthCheck()
+
+	lastCheck := func() float64 {
+		return testutil.ToFloat64(backendHealthCheckSuccess.WithLabelValues("health-check"))
+	}
+
+	require.Eventually(t, func() bool { return lastCheck() == 1 }, time.Second, 5*time.Millisecond)
+	require.True(t, b.IsHealthy())
+	require.NotZero(t, testutil.ToFloat64(backendHealthCheckTimestamp.WithLabelValues("health-check")))
+
+	syncing.Store(true)
+	require.Eventually(t, func() bool { return !b.IsHealthy() }, time.Second, 5*time.Millisecond)
+	require.Equal(t, 0.0, lastCheck())
+
+	syncing.Store(false)
+	require.Eventually(t, b.IsHealthy, time.Second, 5*time.Millisecond)
+	require.Equal(t, 1.0, lastCheck())
+}
+
+func TestHealthCheckExpectedResult(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","result":"0xa","id":1}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		expected json.RawMessage
+		healthy  bool
+	}{
+		{"any result", nil, true},
+		{"expected result", json.RawMessage(`"0xa"`), true},
+		{"unexpected result", json.RawMessage(`"0x1"`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBackend("health-check-"+tt.name, srv.URL, "", semaphore.NewWeighted(10),
+				WithHealthCheck("eth_chainId", tt.expected, time.Second),
+				WithStrippedTrailingXFF(),
+			)
+			b.runHealthCheck(context.Background())
+			require.Equal(t, tt.healthy, b.IsHealthy())
+		})
+	}
+}
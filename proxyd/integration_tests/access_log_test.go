@@ -0,0 +1,75 @@
+package integration_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLog(t *testing.T) {
+	goodBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	output := filepath.Join(t.TempDir(), "access.log")
+	config := ReadConfig("access_log")
+	config.AccessLog.Output = output
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	_, code, err := NewProxydClient("http://127.0.0.1:8545/secret_key").SendRPC("eth_chainId", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+
+	_, code, err = NewProxydClient("http://127.0.0.1:8545/wrong_key").SendRPC("eth_chainId", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "secret_key")
+	require.NotContains(t, string(data), "wrong_key")
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	type accessLogLine struct {
+		RemoteIP  string   `json:"remote_ip"`
+		Auth      string   `json:"auth"`
+		ReqID     string   `json:"req_id"`
+		Path      string   `json:"path"`
+		Methods   []string `json:"methods"`
+		ServedBy  string   `json:"served_by"`
+		Status    int      `json:"status"`
+		Bytes     int      `json:"bytes"`
+		LatencyMS *float64 `json:"latency_ms"`
+	}
+
+	var served accessLogLine
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &served))
+	require.Equal(t, "127.0.0.1", served.RemoteIP)
+	require.Equal(t, "alice", served.Auth)
+	require.NotEmpty(t, served.ReqID)
+	require.Equal(t, "/[redacted]", served.Path)
+	require.Equal(t, []string{"eth_chainId"}, served.Methods)
+	require.Equal(t, "main/good", served.ServedBy)
+	require.Equal(t, http.StatusOK, served.Status)
+	require.Greater(t, served.Bytes, 0)
+	require.NotNil(t, served.LatencyMS)
+
+	var unauthorized accessLogLine
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &unauthorized))
+	require.Equal(t, "127.0.0.1", unauthorized.RemoteIP)
+	require.Empty(t, unauthorized.Auth)
+	require.Equal(t, "/[redacted]", unauthorized.Path)
+	require.Empty(t, unauthorized.Methods)
+	require.Equal(t, http.StatusUnauthorized, unauthorized.Status)
+}
@@ -0,0 +1,47 @@
+package integration_tests
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackendBatchSplit(t *testing.T) {
+	router := NewBatchRPCResponseRouter()
+	router.SetRoute("eth_chainId", "1", "hello1")
+	router.SetRoute("eth_chainId", "2", "hello2")
+	router.SetRoute("eth_chainId", "3", "hello3")
+	router.SetRoute("eth_chainId", "4", "hello4")
+	router.SetRoute("eth_chainId", "5", "hello5")
+
+	goodBackend := NewMockBackend(router)
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	config := ReadConfig("batch_split")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	res, statusCode, err := client.SendBatchRPC(
+		NewRPCReq("1", "eth_chainId", nil),
+		NewRPCReq("2", "eth_chainId", nil),
+		NewRPCReq("3", "eth_chainId", nil),
+		NewRPCReq("4", "eth_chainId", nil),
+		NewRPCReq("5", "eth_chainId", nil),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 200, statusCode)
+	RequireEqualJSON(t, []byte(asArray(
+		`{"jsonrpc": "2.0", "result": "hello1", "id": 1}`,
+		`{"jsonrpc": "2.0", "result": "hello2", "id": 2}`,
+		`{"jsonrpc": "2.0", "result": "hello3", "id": 3}`,
+		`{"jsonrpc": "2.0", "result": "hello4", "id": 4}`,
+		`{"jsonrpc": "2.0", "result": "hello5", "id": 5}`,
+	)), res)
+	require.Equal(t, 3, len(goodBackend.Requests()))
+}
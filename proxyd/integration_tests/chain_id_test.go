@@ -0,0 +1,37 @@
+package integration_tests
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectedChainID(t *testing.T) {
+	nodeBackend := NewMockBackend(nil)
+	defer nodeBackend.Close()
+
+	require.NoError(t, os.Setenv("NODE_BACKEND_RPC_URL", nodeBackend.URL()))
+
+	t.Run("matching chain id starts", func(t *testing.T) {
+		nodeBackend.SetHandler(SingleResponseHandler(200, `{"jsonrpc":"2.0","result":"0xa","id":67}`))
+		_, shutdown, err := proxyd.Start(ReadConfig("chain_id"))
+		require.NoError(t, err)
+		shutdown()
+	})
+
+	t.Run("mismatched chain id refuses to start", func(t *testing.T) {
+		nodeBackend.SetHandler(SingleResponseHandler(200, `{"jsonrpc":"2.0","result":"0x1","id":67}`))
+		_, _, err := proxyd.Start(ReadConfig("chain_id"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "expected 10")
+	})
+
+	t.Run("unreachable backend does not block startup", func(t *testing.T) {
+		nodeBackend.SetHandler(SingleResponseHandler(503, ""))
+		_, shutdown, err := proxyd.Start(ReadConfig("chain_id"))
+		require.NoError(t, err)
+		shutdown()
+	})
+}
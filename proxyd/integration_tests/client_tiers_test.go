@@ -0,0 +1,72 @@
+package integration_tests
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+const tierErrResponse = `{"jsonrpc": "2.0", "error": {"code": -32000, "message": "header not found"}, "id": 999}`
+
+func TestClientTierMetrics(t *testing.T) {
+	goodBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	config := ReadConfig("client_tiers")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	requests := func(tier string) float64 {
+		return tierMetric(t, "proxyd_client_tier_requests_total", tier)
+	}
+	errs := func(tier string) float64 {
+		return tierMetric(t, "proxyd_client_tier_errors_total", tier)
+	}
+
+	premiumBefore, unknownBefore := requests("premium"), requests(proxyd.DefaultClientTier)
+	premiumErrsBefore := errs("premium")
+
+	_, statusCode, err := NewProxydClient("http://127.0.0.1:8545/premium_key").SendRPC("eth_chainId", nil)
+	require.NoError(t, err)
+	require.Equal(t, 200, statusCode)
+
+	// aliases without a tier are reported as unknown
+	_, statusCode, err = NewProxydClient("http://127.0.0.1:8545/free_key").SendRPC("eth_chainId", nil)
+	require.NoError(t, err)
+	require.Equal(t, 200, statusCode)
+
+	goodBackend.SetHandler(BatchedResponseHandler(200, tierErrResponse))
+	_, statusCode, err = NewProxydClient("http://127.0.0.1:8545/premium_key").SendRPC("eth_chainId", nil)
+	require.NoError(t, err)
+	require.Equal(t, 200, statusCode)
+
+	require.Equal(t, premiumBefore+2, requests("premium"))
+	require.Equal(t, unknownBefore+1, requests(proxyd.DefaultClientTier))
+	require.Equal(t, premiumErrsBefore+1, errs("premium"))
+}
+
+func tierMetric(t *testing.T, name string, tier string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "tier" && label.GetValue() == tier {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	// tiers are registered on startup, so the series must exist
+	require.Failf(t, "metric not found", "%s{tier=%q}", name, tier)
+	return 0
+}
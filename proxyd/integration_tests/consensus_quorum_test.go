@@ -0,0 +1,85 @@
+package integration_tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	ms "github.com/ethereum-optimism/infra/proxyd/tools/mockserver/handler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsensusQuorumForkedCandidate(t *testing.T) {
+	dir, err := os.Getwd()
+	require.NoError(t, err)
+	responses := path.Join(dir, "testdata/consensus_responses.yml")
+
+	names := []string{"node1", "node2", "node3"}
+	handlers := make(map[string]*ms.MockedHandler, len(names))
+	for i, name := range names {
+		h := &ms.MockedHandler{
+			Overrides:    []*ms.MethodTemplate{},
+			Autoload:     true,
+			AutoloadFile: responses,
+		}
+		node := NewMockBackend(http.HandlerFunc(h.Handler))
+		defer node.Close()
+		handlers[name] = h
+		require.NoError(t, os.Setenv(fmt.Sprintf("NODE%d_URL", i+1), node.URL()))
+	}
+
+	config := ReadConfig("consensus_quorum")
+	svr, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	bg := svr.BackendGroups["node"]
+	require.NotNil(t, bg.Consensus)
+	backends := make(map[string]*proxyd.Backend, len(names))
+	for _, be := range bg.Backends {
+		backends[be.Name] = be
+	}
+
+	ctx := context.Background()
+	update := func() {
+		for _, be := range bg.Backends {
+			bg.Consensus.UpdateBackend(ctx, be)
+		}
+		bg.Consensus.UpdateBackendGroupConsensus(ctx)
+	}
+	overrideBlockHash := func(node string, blockRequest string, number string, hash string) {
+		handlers[node].AddOverride(&ms.MethodTemplate{
+			Method: "eth_getBlockByNumber",
+			Block:  blockRequest,
+			Response: buildResponse(map[string]string{
+				"number": number,
+				"hash":   hash,
+			}),
+		})
+	}
+
+	update()
+	require.Equal(t, "0x101", bg.Consensus.GetLatestBlockNumber().String())
+
+	// every node advances to 0x102, but node3 is on a fork
+	overrideBlockHash("node1", "latest", "0x102", "hash_0x102")
+	overrideBlockHash("node2", "latest", "0x102", "hash_0x102")
+	overrideBlockHash("node3", "latest", "0x102", "forked_0x102")
+	overrideBlockHash("node3", "0x102", "0x102", "forked_0x102")
+
+	for i := 0; i < 10; i++ {
+		update()
+
+		// the majority agrees on 0x102 whichever backend is asked first, and only the forked node is left out
+		require.Equal(t, "0x102", bg.Consensus.GetLatestBlockNumber().String())
+		consensusGroup := bg.Consensus.GetConsensusGroup()
+		require.Len(t, consensusGroup, 2)
+		require.Contains(t, consensusGroup, backends["node1"])
+		require.Contains(t, consensusGroup, backends["node2"])
+		require.NotContains(t, consensusGroup, backends["node3"])
+	}
+}
@@ -0,0 +1,112 @@
+package integration_tests
+
+import (
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainingBackend(t *testing.T) {
+	maintenanceBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer maintenanceBackend.Close()
+	goodBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("MAINTENANCE_BACKEND_RPC_URL", maintenanceBackend.URL()))
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	config := ReadConfig("draining")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	srv, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	bg := srv.BackendGroups["main"]
+
+	t.Run("drained backend receives no requests", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			res, code, err := client.SendRPC("eth_chainId", nil)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, code)
+			RequireEqualJSON(t, []byte(goodResponse), res)
+		}
+		require.Equal(t, 0, len(maintenanceBackend.Requests()))
+		require.Equal(t, 5, len(goodBackend.Requests()))
+		goodBackend.Reset()
+	})
+
+	t.Run("stopping the drain resumes traffic", func(t *testing.T) {
+		require.NoError(t, bg.StopDrain("maintenance"))
+		_, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, 1, len(maintenanceBackend.Requests()))
+		require.Equal(t, 0, len(goodBackend.Requests()))
+		maintenanceBackend.Reset()
+	})
+
+	t.Run("in flight requests complete", func(t *testing.T) {
+		started := make(chan struct{})
+		maintenanceBackend.SetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			SingleResponseHandler(200, goodResponse)(w, r)
+		}))
+
+		type result struct {
+			res  []byte
+			code int
+			err  error
+		}
+		done := make(chan result)
+		go func() {
+			res, code, err := client.SendRPC("eth_chainId", nil)
+			done <- result{res, code, err}
+		}()
+		<-started
+		require.NoError(t, bg.StartDrain("maintenance"))
+
+		r := <-done
+		require.NoError(t, r.err)
+		require.Equal(t, http.StatusOK, r.code)
+		RequireEqualJSON(t, []byte(goodResponse), r.res)
+		require.Equal(t, 0, len(goodBackend.Requests()))
+	})
+}
+
+func TestDrainingBackendWS(t *testing.T) {
+	var maintenanceConns, goodConns atomic.Int32
+	maintenanceBackend := NewMockWSBackend(func(conn *websocket.Conn) {
+		maintenanceConns.Add(1)
+	}, nil, nil)
+	defer maintenanceBackend.Close()
+	goodBackend := NewMockWSBackend(func(conn *websocket.Conn) {
+		goodConns.Add(1)
+	}, nil, nil)
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("MAINTENANCE_BACKEND_RPC_URL", maintenanceBackend.URL()))
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	config := ReadConfig("draining_ws")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	for i := 0; i < 3; i++ {
+		client, err := NewProxydWSClient("ws://127.0.0.1:8546", nil, nil)
+		require.NoError(t, err)
+		defer client.HardClose()
+	}
+
+	require.Eventually(t, func() bool {
+		return goodConns.Load() == 3
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, int32(0), maintenanceConns.Load())
+}
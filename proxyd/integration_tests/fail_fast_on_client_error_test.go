@@ -0,0 +1,91 @@
+package integration_tests
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailFastOnClientError(t *testing.T) {
+	clientErrorResponse := `{"jsonrpc": "2.0", "error": {"code": -32602, "message": "invalid argument 0"}, "id": 999}`
+
+	badBackend := NewMockBackend(SingleResponseHandler(http.StatusUnprocessableEntity, clientErrorResponse))
+	defer badBackend.Close()
+	goodBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("BAD_BACKEND_RPC_URL", badBackend.URL()))
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	config := ReadConfig("fail_fast_on_client_error")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	t.Run("client error is returned without retries or failover", func(t *testing.T) {
+		badBackend.Reset()
+		goodBackend.Reset()
+
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnprocessableEntity, code)
+		RequireEqualJSON(t, []byte(clientErrorResponse), res)
+		require.Equal(t, 1, len(badBackend.Requests()))
+		require.Equal(t, 0, len(goodBackend.Requests()))
+	})
+
+	t.Run("client error fails over when disabled", func(t *testing.T) {
+		badBackend.Reset()
+		goodBackend.Reset()
+
+		res, code, err := client.SendRPC("net_version", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		require.Equal(t, 2, len(badBackend.Requests()))
+		require.Equal(t, 1, len(goodBackend.Requests()))
+	})
+
+	t.Run("auth error fails over", func(t *testing.T) {
+		unauthorizedResponse := `{"jsonrpc": "2.0", "error": {"code": -32600, "message": "invalid api key"}, "id": 999}`
+		badBackend.SetHandler(SingleResponseHandler(http.StatusUnauthorized, unauthorizedResponse))
+		badBackend.Reset()
+		goodBackend.Reset()
+
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		require.Equal(t, 2, len(badBackend.Requests()))
+		require.Equal(t, 1, len(goodBackend.Requests()))
+	})
+
+	t.Run("server error fails over", func(t *testing.T) {
+		badBackend.SetHandler(SingleResponseHandler(http.StatusServiceUnavailable, unexpectedResponse))
+		badBackend.Reset()
+		goodBackend.Reset()
+
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		require.Equal(t, 2, len(badBackend.Requests()))
+		require.Equal(t, 1, len(goodBackend.Requests()))
+	})
+
+	t.Run("rate limits fail over", func(t *testing.T) {
+		badBackend.SetHandler(SingleResponseHandler(http.StatusTooManyRequests, unexpectedResponse))
+		badBackend.Reset()
+		goodBackend.Reset()
+
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		require.Equal(t, 1, len(goodBackend.Requests()))
+	})
+}
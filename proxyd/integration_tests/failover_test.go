@@ -205,6 +205,53 @@ func TestRetries(t *testing.T) {
 	require.Equal(t, 4, len(backend.Requests()))
 }
 
+func TestNonRetryableErrors(t *testing.T) {
+	backend := NewMockBackend(nil)
+	defer backend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", backend.URL()))
+	config := ReadConfig("retries")
+	config.BackendOptions.NonRetryableErrors = []string{"method not supported"}
+	config.BackendOptions.NonRetryableErrorCodes = []int{-32601}
+	client := NewProxydClient("http://127.0.0.1:8545")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	tests := []struct {
+		name        string
+		body        string
+		expAttempts int
+	}{
+		{
+			name:        "matching error message",
+			body:        `{"jsonrpc":"2.0","error":{"code":-32000,"message":"method not supported on this node"},"id":999}`,
+			expAttempts: 1,
+		},
+		{
+			name:        "matching error code",
+			body:        `{"jsonrpc":"2.0","error":{"code":-32601,"message":"the method does not exist"},"id":999}`,
+			expAttempts: 1,
+		},
+		{
+			name:        "transient error",
+			body:        `{"jsonrpc":"2.0","error":{"code":-32000,"message":"header not found"},"id":999}`,
+			expAttempts: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend.Reset()
+			backend.SetHandler(SingleResponseHandler(500, tt.body))
+			res, statusCode, err := client.SendRPC("eth_chainId", nil)
+			require.NoError(t, err)
+			require.Equal(t, 503, statusCode)
+			RequireEqualJSON(t, []byte(noBackendsResponse), res)
+			require.Equal(t, tt.expAttempts, len(backend.Requests()))
+		})
+	}
+}
+
 func TestOutOfServiceInterval(t *testing.T) {
 	okHandler := BatchedResponseHandler(200, goodResponse)
 	goodBackend := NewMockBackend(okHandler)
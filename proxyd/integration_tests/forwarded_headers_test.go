@@ -0,0 +1,44 @@
+package integration_tests
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardedHeaders(t *testing.T) {
+	goodBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	config := ReadConfig("forwarded_headers")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	headers := make(http.Header)
+	headers.Set("X-Trace-Id", "abc123")
+	headers.Set("X-Api-Tier", "premium")
+	client := NewProxydClientWithHeaders("http://127.0.0.1:8545", headers)
+
+	res, statusCode, err := client.SendRPC("eth_chainId", nil)
+	require.NoError(t, err)
+	require.Equal(t, 200, statusCode)
+	RequireEqualJSON(t, []byte(goodResponse), res)
+
+	require.Equal(t, 1, len(goodBackend.Requests()))
+	backendHeaders := goodBackend.Requests()[0].Headers
+	require.Equal(t, "abc123", backendHeaders.Get("X-Trace-Id"))
+	require.Empty(t, backendHeaders.Get("X-Api-Tier"))
+}
+
+func TestForwardedHeadersRejectsAuthHeaders(t *testing.T) {
+	config := ReadConfig("forwarded_headers")
+	config.Server.ForwardedHeaders = []string{"authorization"}
+	_, _, err := proxyd.Start(config)
+	require.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package integration_tests
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackendGroupFailover(t *testing.T) {
+	goodBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer goodBackend.Close()
+	badBackend := NewMockBackend(SingleResponseHandler(503, ""))
+	defer badBackend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+	require.NoError(t, os.Setenv("BAD_BACKEND_RPC_URL", badBackend.URL()))
+
+	config := ReadConfig("group_failover")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	t.Run("unserviceable primary group fails over", func(t *testing.T) {
+		res, statusCode, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, statusCode)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		require.Equal(t, 1, len(badBackend.Requests()))
+		require.Equal(t, 1, len(goodBackend.Requests()))
+		badBackend.Reset()
+		goodBackend.Reset()
+	})
+
+	t.Run("serviceable primary group does not fail over", func(t *testing.T) {
+		badBackend.SetHandler(BatchedResponseHandler(200, goodResponse))
+		res, statusCode, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, statusCode)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		require.Equal(t, 1, len(badBackend.Requests()))
+		require.Equal(t, 0, len(goodBackend.Requests()))
+		badBackend.Reset()
+		goodBackend.Reset()
+	})
+}
+
+func TestBackendGroupFailoverUndefinedGroup(t *testing.T) {
+	config := ReadConfig("group_failover")
+	config.BackendGroups["primary"].FallbackGroup = "missing"
+	_, _, err := proxyd.Start(config)
+	require.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package integration_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupMaxBatchSize(t *testing.T) {
+	strictRouter := NewBatchRPCResponseRouter()
+	strictRouter.SetFallbackRoute("eth_chainId", "0x1")
+	strictBackend := NewMockBackend(strictRouter)
+	defer strictBackend.Close()
+
+	chunkedRouter := NewBatchRPCResponseRouter()
+	chunkedRouter.SetFallbackRoute("net_version", "1")
+	chunkedBackend := NewMockBackend(chunkedRouter)
+	defer chunkedBackend.Close()
+
+	require.NoError(t, os.Setenv("STRICT_BACKEND_RPC_URL", strictBackend.URL()))
+	require.NoError(t, os.Setenv("CHUNKED_BACKEND_RPC_URL", chunkedBackend.URL()))
+
+	config := ReadConfig("group_max_batch_size")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	batchOf := func(method string, n int) []*proxyd.RPCReq {
+		reqs := make([]*proxyd.RPCReq, n)
+		for i := range reqs {
+			reqs[i] = NewRPCReq(strconv.Itoa(i+1), method, nil)
+		}
+		return reqs
+	}
+
+	t.Run("reject at max size", func(t *testing.T) {
+		strictBackend.Reset()
+		res, code, err := client.SendBatchRPC(batchOf("eth_chainId", 3)...)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(asArray(
+			`{"jsonrpc": "2.0", "result": "0x1", "id": 1}`,
+			`{"jsonrpc": "2.0", "result": "0x1", "id": 2}`,
+			`{"jsonrpc": "2.0", "result": "0x1", "id": 3}`,
+		)), res)
+		require.Equal(t, 1, len(strictBackend.Requests()))
+	})
+
+	t.Run("reject over max size", func(t *testing.T) {
+		strictBackend.Reset()
+		res, code, err := client.SendBatchRPC(batchOf("eth_chainId", 4)...)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		var errRes proxyd.RPCRes
+		require.NoError(t, json.Unmarshal(res, &errRes))
+		require.NotNil(t, errRes.Error)
+		require.Equal(t, proxyd.ErrTooManyBatchRequests.Code, errRes.Error.Code)
+		require.Equal(t, 0, len(strictBackend.Requests()))
+	})
+
+	t.Run("reject counts calls of the group only", func(t *testing.T) {
+		strictBackend.Reset()
+		chunkedBackend.Reset()
+		reqs := append(batchOf("eth_chainId", 3), NewRPCReq("9", "net_version", nil))
+		res, code, err := client.SendBatchRPC(reqs...)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(asArray(
+			`{"jsonrpc": "2.0", "result": "0x1", "id": 1}`,
+			`{"jsonrpc": "2.0", "result": "0x1", "id": 2}`,
+			`{"jsonrpc": "2.0", "result": "0x1", "id": 3}`,
+			`{"jsonrpc": "2.0", "result": "1", "id": 9}`,
+		)), res)
+		require.Equal(t, 1, len(strictBackend.Requests()))
+		require.Equal(t, 1, len(chunkedBackend.Requests()))
+	})
+
+	t.Run("split at max size", func(t *testing.T) {
+		chunkedBackend.Reset()
+		res, code, err := client.SendBatchRPC(batchOf("net_version", 3)...)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(asArray(
+			`{"jsonrpc": "2.0", "result": "1", "id": 1}`,
+			`{"jsonrpc": "2.0", "result": "1", "id": 2}`,
+			`{"jsonrpc": "2.0", "result": "1", "id": 3}`,
+		)), res)
+		require.Equal(t, 1, len(chunkedBackend.Requests()))
+	})
+
+	t.Run("split over max size", func(t *testing.T) {
+		chunkedBackend.Reset()
+		res, code, err := client.SendBatchRPC(batchOf("net_version", 4)...)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(asArray(
+			`{"jsonrpc": "2.0", "result": "1", "id": 1}`,
+			`{"jsonrpc": "2.0", "result": "1", "id": 2}`,
+			`{"jsonrpc": "2.0", "result": "1", "id": 3}`,
+			`{"jsonrpc": "2.0", "result": "1", "id": 4}`,
+		)), res)
+		require.Equal(t, 2, len(chunkedBackend.Requests()))
+	})
+}
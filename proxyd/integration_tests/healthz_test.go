@@ -0,0 +1,49 @@
+package integration_tests
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthz(t *testing.T) {
+	goodBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	config := ReadConfig("healthz")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	t.Run("healthy backends", func(t *testing.T) {
+		statusCode, body := getHealthz(t)
+		require.Equal(t, http.StatusOK, statusCode)
+		require.Equal(t, "OK", body)
+	})
+
+	t.Run("no healthy backends", func(t *testing.T) {
+		// any latency degrades the backend given the 1ns threshold
+		_, statusCode, err := NewProxydClient("http://127.0.0.1:8545").SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, statusCode)
+
+		statusCode, body := getHealthz(t)
+		require.Equal(t, http.StatusServiceUnavailable, statusCode)
+		RequireEqualJSON(t, []byte(`{"status":"unhealthy","reason":"no healthy backends"}`), []byte(body))
+	})
+}
+
+func getHealthz(t *testing.T) (int, string) {
+	res, err := http.Get("http://127.0.0.1:8545/healthz")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, string(body)
+}
@@ -0,0 +1,43 @@
+package integration_tests
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodMaxResponseSize(t *testing.T) {
+	// too large for eth_blockNumber, but within the default limit
+	oversizedResponse := `{"jsonrpc": "2.0", "result": "0x` + strings.Repeat("f", 128) + `", "id": 999}`
+	goodBackend := NewMockBackend(SingleResponseHandler(200, oversizedResponse))
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	config := ReadConfig("method_max_response_size")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	t.Run("method limit exceeded", func(t *testing.T) {
+		res, code, err := client.SendRPC("eth_blockNumber", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusInternalServerError, code)
+		RequireEqualJSON(t, []byte(`{"error":{"code":-32020,"message":"backend response too large"},"id":999,"jsonrpc":"2.0"}`), res)
+		require.Equal(t, 1, len(goodBackend.Requests()))
+		goodBackend.Reset()
+	})
+
+	t.Run("other methods use the default limit", func(t *testing.T) {
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(oversizedResponse), res)
+		goodBackend.Reset()
+	})
+}
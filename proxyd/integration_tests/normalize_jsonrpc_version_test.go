@@ -0,0 +1,56 @@
+package integration_tests
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeJSONRPCVersion(t *testing.T) {
+	goodBackend := NewMockBackend(nil)
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	config := ReadConfig("normalize_jsonrpc_version")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	t.Run("missing jsonrpc field", func(t *testing.T) {
+		goodBackend.SetHandler(SingleResponseHandler(200, `{"result": "hello", "id": 999}`))
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+	})
+
+	t.Run("different jsonrpc version", func(t *testing.T) {
+		goodBackend.SetHandler(SingleResponseHandler(200, `{"jsonrpc": "1.0", "result": "hello", "id": 999}`))
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		goodBackend.SetHandler(BatchedResponseHandler(200,
+			`{"result": "hello", "id": 1}`,
+			`{"jsonrpc": "", "result": "1.0", "id": 2}`,
+		))
+		res, code, err := client.SendBatchRPC(
+			NewRPCReq("1", "eth_chainId", nil),
+			NewRPCReq("2", "net_version", nil),
+		)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(asArray(
+			`{"jsonrpc": "2.0", "result": "hello", "id": 1}`,
+			`{"jsonrpc": "2.0", "result": "1.0", "id": 2}`,
+		)), res)
+	})
+}
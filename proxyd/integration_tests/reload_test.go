@@ -0,0 +1,204 @@
+package integration_tests
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadBackends(t *testing.T) {
+	firstBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer firstBackend.Close()
+	secondBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer secondBackend.Close()
+
+	require.NoError(t, os.Setenv("FIRST_BACKEND_RPC_URL", firstBackend.URL()))
+	require.NoError(t, os.Setenv("SECOND_BACKEND_RPC_URL", secondBackend.URL()))
+
+	config := ReadConfig("reload")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	srv, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	sendRequest := func() {
+		res, statusCode, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, statusCode)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+	}
+
+	sendRequest()
+	require.Equal(t, 1, len(firstBackend.Requests()))
+	require.Equal(t, 0, len(secondBackend.Requests()))
+	firstBackend.Reset()
+
+	t.Run("undefined backend leaves the running config untouched", func(t *testing.T) {
+		newConfig := ReadConfig("reload")
+		newConfig.BackendGroups["main"].Backends = []string{"missing"}
+		_, _, err := srv.ReloadBackends(newConfig)
+		require.Error(t, err)
+
+		sendRequest()
+		require.Equal(t, 1, len(firstBackend.Requests()))
+		firstBackend.Reset()
+	})
+
+	t.Run("added backend receives traffic and removed backend drains", func(t *testing.T) {
+		newConfig := ReadConfig("reload")
+		newConfig.Backends["second"] = &proxyd.BackendConfig{
+			RPCURL: "$SECOND_BACKEND_RPC_URL",
+			WSURL:  "$SECOND_BACKEND_RPC_URL",
+		}
+		delete(newConfig.Backends, "first")
+		newConfig.BackendGroups["main"].Backends = []string{"second"}
+
+		added, removed, err := srv.ReloadBackends(newConfig)
+		require.NoError(t, err)
+		require.Equal(t, 1, added)
+		require.Equal(t, 1, removed)
+
+		sendRequest()
+		require.Equal(t, 0, len(firstBackend.Requests()))
+		require.Equal(t, 1, len(secondBackend.Requests()))
+		secondBackend.Reset()
+	})
+
+	t.Run("changed backend is replaced", func(t *testing.T) {
+		newConfig := ReadConfig("reload")
+		newConfig.Backends["second"] = &proxyd.BackendConfig{
+			RPCURL: "$FIRST_BACKEND_RPC_URL",
+			WSURL:  "$FIRST_BACKEND_RPC_URL",
+		}
+		delete(newConfig.Backends, "first")
+		newConfig.BackendGroups["main"].Backends = []string{"second"}
+
+		added, removed, err := srv.ReloadBackends(newConfig)
+		require.NoError(t, err)
+		require.Equal(t, 1, added)
+		require.Equal(t, 1, removed)
+
+		sendRequest()
+		require.Equal(t, 1, len(firstBackend.Requests()))
+		require.Equal(t, 0, len(secondBackend.Requests()))
+	})
+}
+
+func TestReloadBackendsOnSIGHUP(t *testing.T) {
+	firstBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer firstBackend.Close()
+	secondBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer secondBackend.Close()
+
+	require.NoError(t, os.Setenv("FIRST_BACKEND_RPC_URL", firstBackend.URL()))
+	require.NoError(t, os.Setenv("SECOND_BACKEND_RPC_URL", secondBackend.URL()))
+
+	config := ReadConfig("reload")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	srv, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	reloaded := make(chan struct{}, 1)
+	stop := srv.ReloadOnSignal(func() (*proxyd.Config, error) {
+		defer func() { reloaded <- struct{}{} }()
+		newConfig := ReadConfig("reload")
+		newConfig.Backends["second"] = &proxyd.BackendConfig{
+			RPCURL: "$SECOND_BACKEND_RPC_URL",
+			WSURL:  "$SECOND_BACKEND_RPC_URL",
+		}
+		delete(newConfig.Backends, "first")
+		newConfig.BackendGroups["main"].Backends = []string{"second"}
+		return newConfig, nil
+	})
+	defer stop()
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded on SIGHUP")
+	}
+
+	// the config is loaded before it is applied
+	require.Eventually(t, func() bool {
+		res, statusCode, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, statusCode)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		return len(secondBackend.Requests()) > 0
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestReloadConsensusBackendsRejected(t *testing.T) {
+	node1 := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer node1.Close()
+	node2 := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer node2.Close()
+
+	require.NoError(t, os.Setenv("NODE1_URL", node1.URL()))
+	require.NoError(t, os.Setenv("NODE2_URL", node2.URL()))
+
+	srv, shutdown, err := proxyd.Start(ReadConfig("consensus"))
+	require.NoError(t, err)
+	defer shutdown()
+
+	newConfig := ReadConfig("consensus")
+	delete(newConfig.Backends, "node2")
+	newConfig.BackendGroups["node"].Backends = []string{"node1"}
+	_, _, err = srv.ReloadBackends(newConfig)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "consensus aware backend group node")
+
+	// the rejected config wasn't recorded, so reloading the original one is a no-op
+	added, removed, err := srv.ReloadBackends(ReadConfig("consensus"))
+	require.NoError(t, err)
+	require.Equal(t, 0, added)
+	require.Equal(t, 0, removed)
+}
+
+func TestReloadVerifiesAddedBackendChainID(t *testing.T) {
+	firstBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer firstBackend.Close()
+	secondBackend := NewMockBackend(SingleResponseHandler(200, `{"jsonrpc":"2.0","result":"0x1","id":67}`))
+	defer secondBackend.Close()
+
+	require.NoError(t, os.Setenv("FIRST_BACKEND_RPC_URL", firstBackend.URL()))
+	require.NoError(t, os.Setenv("SECOND_BACKEND_RPC_URL", secondBackend.URL()))
+
+	client := NewProxydClient("http://127.0.0.1:8545")
+	srv, shutdown, err := proxyd.Start(ReadConfig("reload"))
+	require.NoError(t, err)
+	defer shutdown()
+
+	newConfig := func() *proxyd.Config {
+		config := ReadConfig("reload")
+		config.Backends["second"] = &proxyd.BackendConfig{
+			RPCURL: "$SECOND_BACKEND_RPC_URL",
+			WSURL:  "$SECOND_BACKEND_RPC_URL",
+		}
+		config.BackendGroups["main"].Backends = []string{"second"}
+		config.BackendGroups["main"].ExpectedChainID = 10
+		return config
+	}
+
+	_, _, err = srv.ReloadBackends(newConfig())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "expected 10")
+
+	res, statusCode, err := client.SendRPC("eth_chainId", nil)
+	require.NoError(t, err)
+	require.Equal(t, 200, statusCode)
+	RequireEqualJSON(t, []byte(goodResponse), res)
+	require.Equal(t, 1, len(firstBackend.Requests()))
+
+	secondBackend.SetHandler(SingleResponseHandler(200, `{"jsonrpc":"2.0","result":"0xa","id":67}`))
+	added, removed, err := srv.ReloadBackends(newConfig())
+	require.NoError(t, err)
+	require.Equal(t, 1, added)
+	require.Equal(t, 0, removed)
+}
@@ -0,0 +1,60 @@
+package integration_tests
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDHeader(t *testing.T) {
+	generatedBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer generatedBackend.Close()
+	passthroughBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer passthroughBackend.Close()
+
+	require.NoError(t, os.Setenv("GENERATED_BACKEND_RPC_URL", generatedBackend.URL()))
+	require.NoError(t, os.Setenv("PASSTHROUGH_BACKEND_RPC_URL", passthroughBackend.URL()))
+
+	config := ReadConfig("request_id")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	headers := make(http.Header)
+	headers.Set("X-Request-ID", "client-id")
+	client := NewProxydClientWithHeaders("http://127.0.0.1:8545", headers)
+
+	t.Run("proxyd request id is injected", func(t *testing.T) {
+		_, statusCode, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, statusCode)
+
+		require.Equal(t, 1, len(generatedBackend.Requests()))
+		requestID := generatedBackend.Requests()[0].Headers.Get("X-Request-ID")
+		require.NotEmpty(t, requestID)
+		require.NotEqual(t, "client-id", requestID)
+	})
+
+	t.Run("client request id is passed through", func(t *testing.T) {
+		_, statusCode, err := client.SendRPC("net_version", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, statusCode)
+
+		require.Equal(t, 1, len(passthroughBackend.Requests()))
+		require.Equal(t, "client-id", passthroughBackend.Requests()[0].Headers.Get("X-Request-ID"))
+	})
+
+	t.Run("request id is generated without a client value", func(t *testing.T) {
+		passthroughBackend.Reset()
+		client := NewProxydClient("http://127.0.0.1:8545")
+		_, statusCode, err := client.SendRPC("net_version", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, statusCode)
+
+		require.Equal(t, 1, len(passthroughBackend.Requests()))
+		require.NotEmpty(t, passthroughBackend.Requests()[0].Headers.Get("X-Request-ID"))
+	})
+}
@@ -0,0 +1,58 @@
+package integration_tests
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	validBlockResponse     = `{"jsonrpc":"2.0","result":{"number":"0x10","hash":"0x2a2ad3e6a5ee6a87a1a5b2d5e2e6e7d3fcdc2a7a8e8b1e0c0f3d5a6b7c8d9e0f"},"id":999}`
+	malformedBlockResponse = `{"jsonrpc":"2.0","result":{"number":"sixteen","hash":"0x2a2ad3e6a5ee6a87a1a5b2d5e2e6e7d3fcdc2a7a8e8b1e0c0f3d5a6b7c8d9e0f"},"id":999}`
+	malformedReceiptResp   = `{"jsonrpc":"2.0","result":{"status":"0x7"},"id":999}`
+)
+
+func TestResponseValidation(t *testing.T) {
+	badBackend := NewMockBackend(nil)
+	defer badBackend.Close()
+	goodBackend := NewMockBackend(nil)
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("BAD_BACKEND_RPC_URL", badBackend.URL()))
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	config := ReadConfig("response_validation")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	t.Run("strict mode retries on the next backend", func(t *testing.T) {
+		badBackend.SetHandler(SingleResponseHandler(200, malformedBlockResponse))
+		goodBackend.SetHandler(SingleResponseHandler(200, validBlockResponse))
+
+		res, code, err := client.SendRPC("eth_getBlockByNumber", []interface{}{"latest", false})
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		RequireEqualJSON(t, []byte(validBlockResponse), res)
+		require.Equal(t, 1, len(badBackend.Requests()))
+		require.Equal(t, 1, len(goodBackend.Requests()))
+		badBackend.Reset()
+		goodBackend.Reset()
+	})
+
+	t.Run("permissive mode passes the response through", func(t *testing.T) {
+		badBackend.SetHandler(SingleResponseHandler(200, malformedReceiptResp))
+
+		res, code, err := client.SendRPC("eth_getTransactionReceipt", []interface{}{"0x1234"})
+		require.NoError(t, err)
+		require.Equal(t, 200, code)
+		RequireEqualJSON(t, []byte(malformedReceiptResp), res)
+		require.Equal(t, 1, len(badBackend.Requests()))
+		require.Equal(t, 0, len(goodBackend.Requests()))
+		badBackend.Reset()
+		goodBackend.Reset()
+	})
+}
@@ -0,0 +1,50 @@
+package integration_tests
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteRequestIDs(t *testing.T) {
+	router := NewBatchRPCResponseRouter()
+	// the backend only sees the internal IDs, i.e. the positions in the batch
+	router.SetRoute("eth_chainId", "0", "hello0")
+	router.SetRoute("eth_chainId", "1", "hello1")
+	router.SetRoute("net_version", "2", "1.0")
+	router.SetRoute("eth_chainId", "3", "hello3")
+	goodBackend := NewMockBackend(router)
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	config := ReadConfig("rewrite_request_ids")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	res, code, err := client.SendBatchRPC(
+		NewRPCReq("1", "eth_chainId", nil),
+		NewRPCReq("1", "eth_chainId", nil),
+		NewRPCReq("null", "net_version", nil),
+		NewRPCReq("null", "eth_chainId", nil),
+	)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+
+	// duplicate and null IDs are forwarded in a single batch and mapped back in order
+	RequireEqualJSON(t, []byte(asArray(
+		`{"jsonrpc": "2.0", "result": "hello0", "id": 1}`,
+		`{"jsonrpc": "2.0", "result": "hello1", "id": 1}`,
+		`{"jsonrpc": "2.0", "result": "1.0", "id": null}`,
+		`{"jsonrpc": "2.0", "result": "hello3", "id": null}`,
+	)), res)
+	require.Equal(t, 1, len(goodBackend.Requests()))
+	for _, id := range []string{"0", "1", "3"} {
+		require.Equal(t, 1, router.GetNumCalls("eth_chainId", id))
+	}
+}
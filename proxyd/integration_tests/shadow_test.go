@@ -0,0 +1,65 @@
+package integration_tests
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowBackend(t *testing.T) {
+	goodBackend := NewMockBackend(BatchedResponseHandler(200, goodResponse))
+	defer goodBackend.Close()
+	shadowBackend := NewMockBackend(SingleResponseHandlerWithSleep(200, goodResponse, 500*time.Millisecond))
+	defer shadowBackend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+	require.NoError(t, os.Setenv("SHADOW_BACKEND_RPC_URL", shadowBackend.URL()))
+
+	config := ReadConfig("shadow")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	t.Run("shadow does not delay the client", func(t *testing.T) {
+		start := time.Now()
+		res, statusCode, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, statusCode)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		require.Less(t, time.Since(start), 500*time.Millisecond)
+
+		require.Eventually(t, func() bool {
+			return len(shadowBackend.Requests()) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+		require.Equal(t, 1, len(goodBackend.Requests()))
+	})
+
+	t.Run("shadow errors are not returned to the client", func(t *testing.T) {
+		goodBackend.Reset()
+		shadowBackend.Reset()
+		shadowBackend.SetHandler(SingleResponseHandler(500, "internal error"))
+
+		res, statusCode, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, 200, statusCode)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+
+		require.Eventually(t, func() bool {
+			return len(shadowBackend.Requests()) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestShadowBackendCannotBeInGroup(t *testing.T) {
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", "http://127.0.0.1:1"))
+	require.NoError(t, os.Setenv("SHADOW_BACKEND_RPC_URL", "http://127.0.0.1:1"))
+
+	config := ReadConfig("shadow")
+	config.BackendGroups["main"].ShadowBackend = "good"
+	_, _, err := proxyd.Start(config)
+	require.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package integration_tests
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateResponseIDs(t *testing.T) {
+	badBackend := NewMockBackend(nil)
+	defer badBackend.Close()
+	goodBackend := NewMockBackend(nil)
+	defer goodBackend.Close()
+
+	require.NoError(t, os.Setenv("BAD_BACKEND_RPC_URL", badBackend.URL()))
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", goodBackend.URL()))
+
+	config := ReadConfig("validate_response_ids")
+	client := NewProxydClient("http://127.0.0.1:8545")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	t.Run("mismatched id fails over", func(t *testing.T) {
+		badBackend.Reset()
+		goodBackend.Reset()
+		badBackend.SetHandler(SingleResponseHandler(200, `{"jsonrpc": "2.0", "result": "bad", "id": 123}`))
+		goodBackend.SetHandler(SingleResponseHandler(200, goodResponse))
+
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		require.Equal(t, 1, len(badBackend.Requests()))
+		require.Equal(t, 1, len(goodBackend.Requests()))
+	})
+
+	t.Run("mismatched id in batch fails over", func(t *testing.T) {
+		badBackend.Reset()
+		goodBackend.Reset()
+		badBackend.SetHandler(BatchedResponseHandler(200,
+			`{"jsonrpc": "2.0", "result": "bad", "id": 1}`,
+			`{"jsonrpc": "2.0", "result": "bad", "id": 3}`,
+		))
+		goodBackend.SetHandler(BatchedResponseHandler(200,
+			`{"jsonrpc": "2.0", "result": "hello", "id": 1}`,
+			`{"jsonrpc": "2.0", "result": "1.0", "id": 2}`,
+		))
+
+		res, code, err := client.SendBatchRPC(
+			NewRPCReq("1", "eth_chainId", nil),
+			NewRPCReq("2", "net_version", nil),
+		)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(asArray(
+			`{"jsonrpc": "2.0", "result": "hello", "id": 1}`,
+			`{"jsonrpc": "2.0", "result": "1.0", "id": 2}`,
+		)), res)
+		require.Equal(t, 1, len(badBackend.Requests()))
+		require.Equal(t, 1, len(goodBackend.Requests()))
+	})
+
+	t.Run("matching ids are accepted", func(t *testing.T) {
+		badBackend.Reset()
+		goodBackend.Reset()
+		badBackend.SetHandler(SingleResponseHandler(200, goodResponse))
+
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		RequireEqualJSON(t, []byte(goodResponse), res)
+		require.Equal(t, 1, len(badBackend.Requests()))
+		require.Equal(t, 0, len(goodBackend.Requests()))
+	})
+
+	t.Run("mismatched id without another backend", func(t *testing.T) {
+		badBackend.SetHandler(SingleResponseHandler(200, `{"jsonrpc": "2.0", "result": "bad", "id": 123}`))
+		goodBackend.SetHandler(SingleResponseHandler(200, `{"jsonrpc": "2.0", "result": "bad", "id": 456}`))
+
+		res, code, err := client.SendRPC("eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		RequireEqualJSON(t, []byte(noBackendsResponse), res)
+	})
+}
@@ -216,6 +216,23 @@ func TestBatchRPCValidation(t *testing.T) {
 			200,
 			1,
 		},
+		{
+			"interleaved not whitelisted methods",
+			asArray(
+				"{\"jsonrpc\": \"2.0\", \"method\": \"eth_chainId\", \"params\": [], \"id\": 999}",
+				"{\"jsonrpc\": \"2.0\", \"method\": \"subtract\", \"params\": [42, 23], \"id\": 999}",
+				"{\"jsonrpc\": \"2.0\", \"method\": \"eth_chainId\", \"params\": [], \"id\": 999}",
+				"{\"jsonrpc\": \"2.0\", \"method\": \"add\", \"params\": [42, 23], \"id\": 999}",
+			),
+			asArray(
+				goodResponse,
+				notWhitelistedResponse,
+				goodResponse,
+				notWhitelistedResponse,
+			),
+			200,
+			3,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,64 @@
+package integration_tests
+
+import (
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWSAccountsIntercept(t *testing.T) {
+	var backendMsgs atomic.Int32
+	backend := NewMockWSBackend(nil, func(conn *websocket.Conn, msgType int, data []byte) {
+		backendMsgs.Add(1)
+	}, nil)
+	defer backend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", backend.URL()))
+
+	config := ReadConfig("ws_accounts")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	clientHdlr := new(clientHandler)
+	client, err := NewProxydWSClient("ws://127.0.0.1:8546", func(msgType int, data []byte) {
+		clientHdlr.MsgCB(msgType, data)
+	}, nil)
+	require.NoError(t, err)
+	defer client.HardClose()
+
+	tests := []struct {
+		method string
+		expRes string
+	}{
+		{"eth_accounts", "{\"jsonrpc\":\"2.0\",\"result\":[],\"id\":1}"},
+		{"eth_requestAccounts", "{\"jsonrpc\":\"2.0\",\"result\":[],\"id\":1}"},
+		{"eth_coinbase", "{\"jsonrpc\":\"2.0\",\"result\":null,\"id\":1}"},
+		{"wallet_accounts", "{\"jsonrpc\":\"2.0\",\"result\":null,\"id\":1}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			timeout := time.NewTicker(10 * time.Second)
+			doneCh := make(chan struct{}, 1)
+			clientHdlr.SetMsgCB(func(msgType int, data []byte) {
+				require.Equal(t, tt.expRes, string(data))
+				doneCh <- struct{}{}
+			})
+			require.NoError(t, client.WriteMessage(
+				websocket.TextMessage,
+				[]byte("{\"jsonrpc\": \"2.0\", \"method\": \""+tt.method+"\", \"id\": 1}"),
+			))
+			select {
+			case <-timeout.C:
+				t.Fatalf("timed out")
+			case <-doneCh:
+			}
+		})
+	}
+	require.Zero(t, backendMsgs.Load())
+}
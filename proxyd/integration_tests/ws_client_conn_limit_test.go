@@ -0,0 +1,102 @@
+package integration_tests
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/infra/proxyd"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWSClientConnLimit(t *testing.T) {
+	backend := NewMockWSBackend(nil, nil, nil)
+	defer backend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", backend.URL()))
+
+	config := ReadConfig("ws_client_conn_limit")
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	dial := func(key string) (*websocket.Conn, int, error) {
+		conn, res, err := websocket.DefaultDialer.Dial("ws://127.0.0.1:8546/"+key, nil)
+		code := 0
+		if res != nil {
+			code = res.StatusCode
+			_ = res.Body.Close()
+		}
+		return conn, code, err
+	}
+
+	first, _, err := dial("alice_key")
+	require.NoError(t, err)
+	defer first.Close()
+	second, _, err := dial("alice_key")
+	require.NoError(t, err)
+	defer second.Close()
+
+	// the third connection of the same client is rejected
+	_, code, err := dial("alice_key")
+	require.Error(t, err)
+	require.Equal(t, http.StatusTooManyRequests, code)
+
+	// other clients have their own limit
+	other, _, err := dial("bob_key")
+	require.NoError(t, err)
+	defer other.Close()
+
+	// closing a connection frees a slot once proxyd notices the disconnect
+	require.NoError(t, first.Close())
+	require.Eventually(t, func() bool {
+		conn, _, err := dial("alice_key")
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestWSClientConnLimitWithoutAuth(t *testing.T) {
+	backend := NewMockWSBackend(nil, nil, nil)
+	defer backend.Close()
+
+	require.NoError(t, os.Setenv("GOOD_BACKEND_RPC_URL", backend.URL()))
+
+	config := ReadConfig("ws_client_conn_limit")
+	config.Authentication = nil
+	_, shutdown, err := proxyd.Start(config)
+	require.NoError(t, err)
+	defer shutdown()
+
+	dial := func(ip string) (*websocket.Conn, int, error) {
+		h := make(http.Header)
+		h.Set("X-Forwarded-For", ip)
+		conn, res, err := websocket.DefaultDialer.Dial("ws://127.0.0.1:8546", h)
+		code := 0
+		if res != nil {
+			code = res.StatusCode
+			_ = res.Body.Close()
+		}
+		return conn, code, err
+	}
+
+	for i := 0; i < 2; i++ {
+		conn, _, err := dial("203.0.113.1")
+		require.NoError(t, err)
+		defer conn.Close()
+	}
+
+	// unauthenticated clients are limited by their IP
+	_, code, err := dial("203.0.113.1")
+	require.Error(t, err)
+	require.Equal(t, http.StatusTooManyRequests, code)
+
+	other, _, err := dial("203.0.113.2")
+	require.NoError(t, err)
+	defer other.Close()
+}
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/sync/singleflight"
 )
 
 type RPCMethodHandler interface {
@@ -24,6 +26,10 @@ type StaticMethodHandler struct {
 }
 
 func (e *StaticMethodHandler) key(req *RPCReq) string {
+	return rpcCacheKey(req)
+}
+
+func rpcCacheKey(req *RPCReq) string {
 	// signature is the hashed json.RawMessage param contents
 	h := sha256.New()
 	h.Write(req.Params)
@@ -90,3 +96,109 @@ func (e *StaticMethodHandler) PutRPCMethod(ctx context.Context, req *RPCReq, res
 	}
 	return nil
 }
+
+const staleWhileRevalidateTimeout = 10 * time.Second
+
+// RPCCacheRefresher forwards a request on behalf of the cache, to refresh a stale entry
+type RPCCacheRefresher func(ctx context.Context, req *RPCReq) (*RPCRes, error)
+
+// StaleWhileRevalidateHandler caches the responses of a mutable method. Entries are fresh for ttl,
+// then served stale for staleTTL while a single background request per key refreshes them.
+type StaleWhileRevalidateHandler struct {
+	cache    Cache
+	ttl      time.Duration
+	staleTTL time.Duration
+	refresh  RPCCacheRefresher
+	group    singleflight.Group
+	now      func() time.Time
+}
+
+type staleWhileRevalidateEntry struct {
+	Result   json.RawMessage `json:"result"`
+	CachedAt int64           `json:"cached_at"`
+}
+
+func NewStaleWhileRevalidateHandler(cache Cache, ttl time.Duration, staleTTL time.Duration) *StaleWhileRevalidateHandler {
+	return &StaleWhileRevalidateHandler{
+		cache:    cache,
+		ttl:      ttl,
+		staleTTL: staleTTL,
+		now:      time.Now,
+	}
+}
+
+func (e *StaleWhileRevalidateHandler) GetRPCMethod(ctx context.Context, req *RPCReq) (*RPCRes, error) {
+	key := rpcCacheKey(req)
+	val, err := e.cache.Get(ctx, key)
+	if err != nil {
+		log.Error("error reading from cache", "key", key, "method", req.Method, "err", err)
+		return nil, err
+	}
+	if val == "" {
+		return nil, nil
+	}
+
+	var entry staleWhileRevalidateEntry
+	if err := json.Unmarshal([]byte(val), &entry); err != nil {
+		log.Error("error unmarshalling value from cache", "key", key, "method", req.Method, "err", err)
+		return nil, err
+	}
+
+	age := e.now().Sub(time.UnixMilli(entry.CachedAt))
+	if age >= e.ttl+e.staleTTL {
+		return nil, nil
+	}
+	if age >= e.ttl {
+		RecordCacheStaleHit(req.Method)
+		e.revalidate(key, req)
+	}
+
+	var result interface{}
+	if err := json.Unmarshal(entry.Result, &result); err != nil {
+		log.Error("error unmarshalling value from cache", "key", key, "method", req.Method, "err", err)
+		return nil, err
+	}
+	return &RPCRes{
+		JSONRPC: req.JSONRPC,
+		Result:  result,
+		ID:      req.ID,
+	}, nil
+}
+
+func (e *StaleWhileRevalidateHandler) PutRPCMethod(ctx context.Context, req *RPCReq, res *RPCRes) error {
+	key := rpcCacheKey(req)
+	value := mustMarshalJSON(staleWhileRevalidateEntry{
+		Result:   mustMarshalJSON(res.Result),
+		CachedAt: e.now().UnixMilli(),
+	})
+
+	err := e.cache.Put(ctx, key, string(value))
+	if err != nil {
+		log.Error("error putting into cache", "key", key, "method", req.Method, "err", err)
+		return err
+	}
+	return nil
+}
+
+// revalidate refreshes an entry in the background. Concurrent calls for the same
+// key share a single refresh, and don't wait for it to complete.
+func (e *StaleWhileRevalidateHandler) revalidate(key string, req *RPCReq) {
+	if e.refresh == nil {
+		return
+	}
+	refreshReq := *req
+	e.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), staleWhileRevalidateTimeout)
+		defer cancel()
+
+		res, err := e.refresh(ctx, &refreshReq)
+		if err != nil {
+			log.Warn("error revalidating cache entry", "key", key, "method", refreshReq.Method, "err", err)
+			return nil, err
+		}
+		if res.Error != nil || res.Result == nil {
+			return nil, nil
+		}
+		return nil, e.PutRPCMethod(ctx, &refreshReq, res)
+	})
+}
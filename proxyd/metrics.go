@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -100,6 +101,14 @@ var (
 		"auth",
 	})
 
+	wsConnsRejectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "ws_conns_rejected_total",
+		Help:      "Count of client WS connections rejected for exceeding the per client limit.",
+	}, []string{
+		"auth",
+	})
+
 	activeBackendWsConnsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: MetricsNamespace,
 		Name:      "active_backend_ws_conns",
@@ -184,6 +193,14 @@ var (
 		"method",
 	})
 
+	cacheStaleHitsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "cache_stale_hits_total",
+		Help:      "Number of stale cache entries served while being revalidated.",
+	}, []string{
+		"method",
+	})
+
 	cacheErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: MetricsNamespace,
 		Name:      "cache_errors_total",
@@ -246,6 +263,39 @@ var (
 		},
 	})
 
+	rejectedMethodsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "rpc_rejected_methods_total",
+		Help:      "Count of requests rejected because their method isn't whitelisted",
+	}, []string{
+		"method",
+	})
+
+	batchForwardSizeHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "batch_forward_size",
+		Help:      "Histogram of the sizes of batches forwarded to a backend group",
+		Buckets: []float64{
+			1,
+			5,
+			10,
+			25,
+			50,
+			100,
+		},
+	}, []string{
+		"backend_group_name",
+	})
+
+	batchForwardMethodsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "batch_forward_methods_total",
+		Help:      "Count of batches forwarded to a backend group containing a method",
+	}, []string{
+		"backend_group_name",
+		"method",
+	})
+
 	frontendRateLimitTakeErrors = promauto.NewCounter(prometheus.CounterOpts{
 		Namespace: MetricsNamespace,
 		Name:      "rate_limit_take_errors",
@@ -407,6 +457,39 @@ var (
 		"backend_name",
 	})
 
+	slowBackendRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "backend_slow_requests_total",
+		Help:      "Count of requests forwarded to a backend that exceeded the slow request threshold",
+	}, []string{
+		"backend_name",
+		"method",
+	})
+
+	backendHealthCheckSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "backend_health_check_success",
+		Help:      "Bool gauge for if the last active health check of a backend succeeded",
+	}, []string{
+		"backend_name",
+	})
+
+	backendHealthCheckTimestamp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "backend_health_check_timestamp_seconds",
+		Help:      "Unix timestamp of the last active health check of a backend",
+	}, []string{
+		"backend_name",
+	})
+
+	effectiveHealthyBackends = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "backend_effective_healthy",
+		Help:      "Bool gauge for backends considered healthy for routing, after health hysteresis",
+	}, []string{
+		"backend_name",
+	})
+
 	degradedBackends = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: MetricsNamespace,
 		Name:      "backend_degraded",
@@ -431,6 +514,15 @@ var (
 		"backend_group_name",
 	})
 
+	backendDraining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "backend_draining",
+		Help:      "Bool gauge for if a backend is drained from new requests of a backend group",
+	}, []string{
+		"backend_group_name",
+		"backend_name",
+	})
+
 	backendGroupFallbackBackend = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: MetricsNamespace,
 		Name:      "backend_group_fallback_backenend",
@@ -459,6 +551,144 @@ var (
 		"backend_name",
 		"error",
 	})
+
+	responseValidationFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "response_validation_failures_total",
+		Help:      "Count of backend responses that failed response validation.",
+	}, []string{
+		"method",
+		"rule",
+	})
+
+	backendGroupFailoversTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "backend_group_failovers_total",
+		Help:      "Count of requests failed over from an unserviceable backend group to its fallback group.",
+	}, []string{
+		"backend_group",
+		"fallback_group",
+	})
+
+	batchSplitTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "batch_split_total",
+		Help:      "Count of batches split into sub-batches to stay within a backend's batch limit.",
+	}, []string{
+		"backend",
+	})
+
+	batchSplitSubBatches = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "batch_split_sub_batches",
+		Help:      "Histogram of the number of sub-batches a split batch is sent as.",
+		Buckets:   []float64{2, 3, 5, 10, 25, 50},
+	}, []string{
+		"backend",
+	})
+
+	accountsInterceptedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "accounts_intercepted_total",
+		Help:      "Count of account method requests answered by proxyd instead of being forwarded.",
+	}, []string{
+		"method",
+	})
+
+	requestIDInjectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "request_id_injected_total",
+		Help:      "Count of request IDs injected into requests sent to a backend.",
+	}, []string{
+		"backend",
+	})
+
+	shadowRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "shadow_requests_total",
+		Help:      "Count of requests mirrored to a shadow backend, by comparison outcome.",
+	}, []string{
+		"backend_group_name",
+		"backend_name",
+		"outcome",
+	})
+
+	canaryChecksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "canary_checks_total",
+		Help:      "Count of canary requests sent to a backend, by comparison outcome.",
+	}, []string{
+		"backend_group_name",
+		"backend_name",
+		"method",
+		"outcome",
+	})
+
+	canaryDivergedBackends = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "backend_canary_diverged",
+		Help:      "Bool gauge for if a backend diverged from its group on the last canary requests",
+	}, []string{
+		"backend_group_name",
+		"backend_name",
+	})
+
+	softRateLimitQueuedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "soft_rate_limit_queued_total",
+		Help:      "Count of rate limited requests queued until the next rate limit interval.",
+	}, []string{
+		"auth",
+	})
+
+	softRateLimitQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "soft_rate_limit_queue_depth",
+		Help:      "Number of rate limited requests currently queued.",
+	}, []string{
+		"auth",
+	})
+
+	clientTierRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "client_tier_requests_total",
+		Help:      "Count of client HTTP RPC requests by client tier.",
+	}, []string{
+		"tier",
+	})
+
+	clientTierErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "client_tier_errors_total",
+		Help:      "Count of RPC error responses returned to clients by client tier.",
+	}, []string{
+		"tier",
+	})
+
+	clientTierRequestDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "client_tier_request_duration_seconds",
+		Help:      "Histogram of client HTTP RPC request durations by client tier.",
+	}, []string{
+		"tier",
+	})
+
+	crossZoneRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "cross_zone_requests_total",
+		Help:      "Count of requests served by a backend outside of proxyd's local zone.",
+	}, []string{
+		"backend_group_name",
+		"backend_name",
+	})
+
+	logSamplesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "log_samples_total",
+		Help:      "Count of log sampling decisions made for requests.",
+	}, []string{
+		"sampled",
+	})
 )
 
 func RecordRedisError(source string) {
@@ -516,6 +746,10 @@ func RecordCacheMiss(method string) {
 	cacheMissesTotal.WithLabelValues(method).Inc()
 }
 
+func RecordCacheStaleHit(method string) {
+	cacheStaleHitsTotal.WithLabelValues(method).Inc()
+}
+
 func RecordCacheError(method string) {
 	cacheErrorsTotal.WithLabelValues(method).Inc()
 }
@@ -524,6 +758,149 @@ func RecordBatchSize(size int) {
 	batchSizeHistogram.Observe(float64(size))
 }
 
+const MethodOther = "other"
+
+// standardRejectedMethods are the methods of the standard eth, debug and trace namespaces, which are
+// labelled in rpc_rejected_methods_total along with the configured rejected_methods
+var standardRejectedMethods = []string{
+	"eth_accounts",
+	"eth_blobBaseFee",
+	"eth_blockNumber",
+	"eth_call",
+	"eth_chainId",
+	"eth_coinbase",
+	"eth_createAccessList",
+	"eth_estimateGas",
+	"eth_feeHistory",
+	"eth_gasPrice",
+	"eth_getBalance",
+	"eth_getBlockByHash",
+	"eth_getBlockByNumber",
+	"eth_getBlockReceipts",
+	"eth_getBlockTransactionCountByHash",
+	"eth_getBlockTransactionCountByNumber",
+	"eth_getCode",
+	"eth_getFilterChanges",
+	"eth_getFilterLogs",
+	"eth_getLogs",
+	"eth_getProof",
+	"eth_getStorageAt",
+	"eth_getTransactionByBlockHashAndIndex",
+	"eth_getTransactionByBlockNumberAndIndex",
+	"eth_getTransactionByHash",
+	"eth_getTransactionCount",
+	"eth_getTransactionReceipt",
+	"eth_getUncleByBlockHashAndIndex",
+	"eth_getUncleByBlockNumberAndIndex",
+	"eth_getUncleCountByBlockHash",
+	"eth_getUncleCountByBlockNumber",
+	"eth_maxPriorityFeePerGas",
+	"eth_mining",
+	"eth_newBlockFilter",
+	"eth_newFilter",
+	"eth_newPendingTransactionFilter",
+	"eth_sendRawTransaction",
+	"eth_sendTransaction",
+	"eth_sign",
+	"eth_signTransaction",
+	"eth_simulateV1",
+	"eth_subscribe",
+	"eth_syncing",
+	"eth_uninstallFilter",
+	"eth_unsubscribe",
+	"debug_getBadBlocks",
+	"debug_getRawBlock",
+	"debug_getRawHeader",
+	"debug_getRawReceipts",
+	"debug_getRawTransaction",
+	"debug_traceBlockByHash",
+	"debug_traceBlockByNumber",
+	"debug_traceCall",
+	"debug_traceTransaction",
+	"trace_block",
+	"trace_call",
+	"trace_callMany",
+	"trace_filter",
+	"trace_get",
+	"trace_rawTransaction",
+	"trace_replayBlockTransactions",
+	"trace_replayTransaction",
+	"trace_transaction",
+}
+
+var (
+	rejectedMethodsMtx sync.RWMutex
+	rejectedMethods    = func() map[string]bool {
+		methods := make(map[string]bool, len(standardRejectedMethods))
+		for _, method := range standardRejectedMethods {
+			methods[method] = true
+		}
+		return methods
+	}()
+)
+
+// RegisterRejectedMethods adds methods labelled in rpc_rejected_methods_total, other methods are
+// counted as MethodOther since clients control the names of rejected methods
+func RegisterRejectedMethods(methods []string) {
+	rejectedMethodsMtx.Lock()
+	defer rejectedMethodsMtx.Unlock()
+	for _, method := range methods {
+		rejectedMethods[method] = true
+	}
+}
+
+// rejectedMethodLabel returns the label of a rejected method, or MethodOther if the method isn't known
+func rejectedMethodLabel(method string) string {
+	rejectedMethodsMtx.RLock()
+	defer rejectedMethodsMtx.RUnlock()
+	if !rejectedMethods[method] {
+		return MethodOther
+	}
+	return method
+}
+
+func RecordRejectedMethod(method string) {
+	rejectedMethodsTotal.WithLabelValues(rejectedMethodLabel(method)).Inc()
+}
+
+var (
+	batchMethodsMtx sync.RWMutex
+	batchMethods    = make(map[string]bool)
+)
+
+// RegisterBatchMethods sets the methods labelled in the batch composition metrics,
+// other methods are counted as MethodOther to keep the label cardinality bounded
+func RegisterBatchMethods(methods []string) {
+	batchMethodsMtx.Lock()
+	defer batchMethodsMtx.Unlock()
+	for _, method := range methods {
+		batchMethods[method] = true
+	}
+}
+
+// RecordBatchComposition records the size of a forwarded batch, and counts each distinct method it contains once
+func RecordBatchComposition(bg *BackendGroup, reqs []*RPCReq) []string {
+	batchForwardSizeHistogram.WithLabelValues(bg.Name).Observe(float64(len(reqs)))
+
+	batchMethodsMtx.RLock()
+	defer batchMethodsMtx.RUnlock()
+	seen := make(map[string]bool, len(reqs))
+	methods := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		method := req.Method
+		if !batchMethods[method] {
+			method = MethodOther
+		}
+		if seen[method] {
+			continue
+		}
+		seen[method] = true
+		methods = append(methods, method)
+		batchForwardMethodsTotal.WithLabelValues(bg.Name, method).Inc()
+	}
+	return methods
+}
+
 var nonAlphanumericRegex = regexp.MustCompile(`[^a-zA-Z ]+`)
 
 func RecordGroupConsensusError(group *BackendGroup, label string, err error) {
@@ -586,6 +963,19 @@ func RecordBackendUnexpectedBlockTags(b *Backend, unexpected bool) {
 	backendUnexpectedBlockTagsBackend.WithLabelValues(b.Name).Set(boolToFloat64(unexpected))
 }
 
+func RecordSlowBackendRequest(b *Backend, method string) {
+	slowBackendRequestsTotal.WithLabelValues(b.Name, method).Inc()
+}
+
+func RecordBackendHealthCheck(b *Backend, success bool, at time.Time) {
+	backendHealthCheckSuccess.WithLabelValues(b.Name).Set(boolToFloat64(success))
+	backendHealthCheckTimestamp.WithLabelValues(b.Name).Set(float64(at.Unix()))
+}
+
+func RecordBackendEffectiveHealthy(b *Backend, healthy bool) {
+	effectiveHealthyBackends.WithLabelValues(b.Name).Set(boolToFloat64(healthy))
+}
+
 func RecordConsensusBackendBanned(b *Backend, banned bool) {
 	consensusBannedBackends.WithLabelValues(b.Name).Set(boolToFloat64(banned))
 }
@@ -624,6 +1014,10 @@ func RecordBackendGroupFallbacks(bg *BackendGroup, name string, fallback bool) {
 	backendGroupFallbackBackend.WithLabelValues(bg.Name, name, strconv.FormatBool(fallback)).Set(boolToFloat64(fallback))
 }
 
+func RecordBackendDraining(bg *BackendGroup, name string, draining bool) {
+	backendDraining.WithLabelValues(bg.Name, name).Set(boolToFloat64(draining))
+}
+
 func RecordBackendGroupMulticallRequest(bg *BackendGroup, backendName string) {
 	backendGroupMulticallCounter.WithLabelValues(bg.Name, backendName).Inc()
 }
@@ -632,6 +1026,74 @@ func RecordBackendGroupMulticallCompletion(bg *BackendGroup, backendName string,
 	backendGroupMulticallCompletionCounter.WithLabelValues(bg.Name, backendName, error).Inc()
 }
 
+func RecordResponseValidationFailure(method string, rule string) {
+	responseValidationFailuresTotal.WithLabelValues(method, rule).Inc()
+}
+
+func RecordBackendGroupFailover(bg *BackendGroup, fallback *BackendGroup) {
+	backendGroupFailoversTotal.WithLabelValues(bg.Name, fallback.Name).Inc()
+}
+
+func RecordBatchSplit(backend string, subBatches int) {
+	batchSplitTotal.WithLabelValues(backend).Inc()
+	batchSplitSubBatches.WithLabelValues(backend).Observe(float64(subBatches))
+}
+
+func RecordAccountsIntercepted(method string) {
+	accountsInterceptedTotal.WithLabelValues(method).Inc()
+}
+
+func RecordRequestIDInjected(backend string) {
+	requestIDInjectedTotal.WithLabelValues(backend).Inc()
+}
+
+func RecordWSConnRejected(ctx context.Context) {
+	wsConnsRejectedTotal.WithLabelValues(GetAuthCtx(ctx)).Inc()
+}
+
+func RecordShadowRequest(group string, backend string, outcome string) {
+	shadowRequestsTotal.WithLabelValues(group, backend, outcome).Inc()
+}
+
+func RecordCanaryCheck(group string, backend string, method string, outcome string) {
+	canaryChecksTotal.WithLabelValues(group, backend, method, outcome).Inc()
+}
+
+func RecordCanaryDiverged(group string, backend string, diverged bool) {
+	canaryDivergedBackends.WithLabelValues(group, backend).Set(boolToFloat64(diverged))
+}
+
+// RegisterClientTiers initializes the client tier metrics, so that every configured tier is
+// exported even before it serves a request
+func RegisterClientTiers(tiers []string) {
+	for _, tier := range append(tiers, DefaultClientTier) {
+		clientTierRequestsTotal.WithLabelValues(tier)
+		clientTierErrorsTotal.WithLabelValues(tier)
+		clientTierRequestDurationSeconds.WithLabelValues(tier)
+	}
+}
+
+func RecordClientTierRequest(ctx context.Context, duration time.Duration) {
+	tier := GetClientTier(ctx)
+	clientTierRequestsTotal.WithLabelValues(tier).Inc()
+	clientTierRequestDurationSeconds.WithLabelValues(tier).Observe(duration.Seconds())
+}
+
+func RecordClientTierErrors(ctx context.Context, count int) {
+	if count == 0 {
+		return
+	}
+	clientTierErrorsTotal.WithLabelValues(GetClientTier(ctx)).Add(float64(count))
+}
+
+func RecordCrossZoneRequest(bg *BackendGroup, be *Backend) {
+	crossZoneRequestsTotal.WithLabelValues(bg.Name, be.Name).Inc()
+}
+
+func RecordLogSample(sampled bool) {
+	logSamplesTotal.WithLabelValues(strconv.FormatBool(sampled)).Inc()
+}
+
 func boolToFloat64(b bool) float64 {
 	if b {
 		return 1
@@ -0,0 +1,24 @@
+package proxyd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRejectedMethodLabel(t *testing.T) {
+	require.Equal(t, "eth_sendTransaction", rejectedMethodLabel("eth_sendTransaction"))
+	require.Equal(t, "debug_traceCall", rejectedMethodLabel("debug_traceCall"))
+	require.Equal(t, MethodOther, rejectedMethodLabel("eth_call\n"))
+	require.Equal(t, MethodOther, rejectedMethodLabel(""))
+
+	// unknown methods don't get a label of their own, however many there are
+	for i := 0; i < 200; i++ {
+		require.Equal(t, MethodOther, rejectedMethodLabel(fmt.Sprintf("eth_method_%d", i)))
+	}
+
+	require.Equal(t, MethodOther, rejectedMethodLabel("admin_peers"))
+	RegisterRejectedMethods([]string{"admin_peers"})
+	require.Equal(t, "admin_peers", rejectedMethodLabel("admin_peers"))
+}
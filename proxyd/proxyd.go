@@ -3,17 +3,20 @@ package proxyd
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -38,6 +41,23 @@ func Start(config *Config) (*Server, func(), error) {
 			return nil, nil, errors.New("cannot use none as an auth key")
 		}
 	}
+	clientTiers := make([]string, 0, len(config.ClientTiers))
+	for alias, tier := range config.ClientTiers {
+		if tier == "" {
+			return nil, nil, fmt.Errorf("empty client tier for auth alias %s", alias)
+		}
+		if !slices.Contains(clientTiers, tier) {
+			clientTiers = append(clientTiers, tier)
+		}
+	}
+	RegisterClientTiers(clientTiers)
+
+	batchMethods := make([]string, 0, len(config.RPCMethodMappings))
+	for method := range config.RPCMethodMappings {
+		batchMethods = append(batchMethods, method)
+	}
+	RegisterBatchMethods(batchMethods)
+	RegisterRejectedMethods(config.Metrics.RejectedMethods)
 
 	// redis primary client
 	var redisClient redis.UniversalClient
@@ -87,6 +107,14 @@ func Start(config *Config) (*Server, func(), error) {
 	if redisClient == nil && config.RateLimit.UseRedis {
 		return nil, nil, errors.New("must specify a Redis URL if UseRedis is true in rate limit config")
 	}
+	if config.Logging.SampleRate < 0 || config.Logging.SampleRate > 1 {
+		return nil, nil, errors.New("sample_rate in logging config must be between 0 and 1")
+	}
+	switch config.RateLimit.Mode {
+	case "", RateLimitModeHard, RateLimitModeSoft:
+	default:
+		return nil, nil, fmt.Errorf("invalid rate limit mode: %s", config.RateLimit.Mode)
+	}
 
 	// While modifying shared globals is a bad practice, the alternative
 	// is to clone these errors on every invocation. This is inefficient.
@@ -111,6 +139,16 @@ func Start(config *Config) (*Server, func(), error) {
 		}
 	}
 
+	forwardedHeaders, err := validateForwardedHeaders(config.Server.ForwardedHeaders)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	localZone, err := ReadFromEnvOrConfig(config.Server.LocalZone)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	maxConcurrentRPCs := config.Server.MaxConcurrentRPCs
 	if maxConcurrentRPCs == 0 {
 		maxConcurrentRPCs = math.MaxInt64
@@ -120,101 +158,17 @@ func Start(config *Config) (*Server, func(), error) {
 	backendNames := make([]string, 0)
 	backendsByName := make(map[string]*Backend)
 	for name, cfg := range config.Backends {
-		opts := make([]BackendOpt, 0)
-
-		rpcURL, err := ReadFromEnvOrConfig(cfg.RPCURL)
-		if err != nil {
-			return nil, nil, err
-		}
-		wsURL, err := ReadFromEnvOrConfig(cfg.WSURL)
-		if err != nil {
-			return nil, nil, err
-		}
-		if rpcURL == "" {
-			return nil, nil, fmt.Errorf("must define an RPC URL for backend %s", name)
-		}
-
-		if config.BackendOptions.ResponseTimeoutSeconds != 0 {
-			timeout := secondsToDuration(config.BackendOptions.ResponseTimeoutSeconds)
-			opts = append(opts, WithTimeout(timeout))
-		}
-		if config.BackendOptions.MaxRetries != 0 {
-			opts = append(opts, WithMaxRetries(config.BackendOptions.MaxRetries))
-		}
-		if config.BackendOptions.MaxResponseSizeBytes != 0 {
-			opts = append(opts, WithMaxResponseSize(config.BackendOptions.MaxResponseSizeBytes))
-		}
-		if config.BackendOptions.OutOfServiceSeconds != 0 {
-			opts = append(opts, WithOutOfServiceDuration(secondsToDuration(config.BackendOptions.OutOfServiceSeconds)))
-		}
-		if config.BackendOptions.MaxDegradedLatencyThreshold > 0 {
-			opts = append(opts, WithMaxDegradedLatencyThreshold(time.Duration(config.BackendOptions.MaxDegradedLatencyThreshold)))
-		}
-		if config.BackendOptions.MaxLatencyThreshold > 0 {
-			opts = append(opts, WithMaxLatencyThreshold(time.Duration(config.BackendOptions.MaxLatencyThreshold)))
-		}
-		if config.BackendOptions.MaxErrorRateThreshold > 0 {
-			opts = append(opts, WithMaxErrorRateThreshold(config.BackendOptions.MaxErrorRateThreshold))
-		}
-		if cfg.MaxRPS != 0 {
-			opts = append(opts, WithMaxRPS(cfg.MaxRPS))
-		}
-		if cfg.MaxWSConns != 0 {
-			opts = append(opts, WithMaxWSConns(cfg.MaxWSConns))
-		}
-		if cfg.Password != "" {
-			passwordVal, err := ReadFromEnvOrConfig(cfg.Password)
-			if err != nil {
-				return nil, nil, err
-			}
-			opts = append(opts, WithBasicAuth(cfg.Username, passwordVal))
-		}
-
-		headers := map[string]string{}
-		for headerName, headerValue := range cfg.Headers {
-			headerValue, err := ReadFromEnvOrConfig(headerValue)
-			if err != nil {
-				return nil, nil, err
-			}
-
-			headers[headerName] = headerValue
-		}
-		opts = append(opts, WithHeaders(headers))
-
-		tlsConfig, err := configureBackendTLS(cfg)
-		if err != nil {
-			return nil, nil, err
-		}
-		if tlsConfig != nil {
-			log.Info("using custom TLS config for backend", "name", name)
-			opts = append(opts, WithTLSConfig(tlsConfig))
-		}
-		if cfg.StripTrailingXFF {
-			opts = append(opts, WithStrippedTrailingXFF())
-		}
-		opts = append(opts, WithProxydIP(os.Getenv("PROXYD_IP")))
-		opts = append(opts, WithConsensusSkipPeerCountCheck(cfg.ConsensusSkipPeerCountCheck))
-		opts = append(opts, WithConsensusForcedCandidate(cfg.ConsensusForcedCandidate))
-		opts = append(opts, WithWeight(cfg.Weight))
-
-		receiptsTarget, err := ReadFromEnvOrConfig(cfg.ConsensusReceiptsTarget)
+		back, err := newBackendFromConfig(name, cfg, config.BackendOptions, rpcRequestSemaphore)
 		if err != nil {
 			return nil, nil, err
 		}
-		receiptsTarget, err = validateReceiptsTarget(receiptsTarget)
-		if err != nil {
-			return nil, nil, err
-		}
-		opts = append(opts, WithConsensusReceiptTarget(receiptsTarget))
-
-		back := NewBackend(name, rpcURL, wsURL, rpcRequestSemaphore, opts...)
 		backendNames = append(backendNames, name)
 		backendsByName[name] = back
 		log.Info("configured backend",
 			"name", name,
 			"backend_names", backendNames,
-			"rpc_url", rpcURL,
-			"ws_url", wsURL)
+			"rpc_url", back.rpcURL,
+			"ws_url", back.wsURL)
 	}
 
 	backendGroups := make(map[string]*BackendGroup)
@@ -256,6 +210,27 @@ func Start(config *Config) (*Server, func(), error) {
 				)
 		}
 
+		if bg.MaxBlockDepth > 0 && !bg.ConsensusAware {
+			return nil, nil, fmt.Errorf("max_block_depth for backend group %s requires consensus_aware", bgName)
+		}
+
+		if bg.ConsensusLatencyWeighted && !bg.ConsensusAware && bg.RoutingStrategy != ConsensusAwareRoutingStrategy {
+			return nil, nil, fmt.Errorf("consensus_latency_weighted for backend group %s requires consensus_aware", bgName)
+		}
+
+		if bg.ConsensusQuorum != 0 && (bg.ConsensusQuorum < 0.5 || bg.ConsensusQuorum > 1) {
+			return nil, nil, fmt.Errorf("consensus_quorum for backend group %s must be between 0.5 and 1", bgName)
+		}
+
+		var responseValidation *ValidationRuleSet
+		if bg.ResponseValidation.Mode != "" {
+			rs, err := NewValidationRuleSet(bg.ResponseValidation)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid response validation for backend group %s: %w", bgName, err)
+			}
+			responseValidation = rs
+		}
+
 		backendGroups[bgName] = &BackendGroup{
 			Name:                   bgName,
 			Backends:               backends,
@@ -263,6 +238,96 @@ func Start(config *Config) (*Server, func(), error) {
 			FallbackBackends:       fallbackBackends,
 			routingStrategy:        bg.RoutingStrategy,
 			multicallRPCErrorCheck: bg.MulticallRPCErrorCheck,
+			responseValidation:     responseValidation,
+		}
+		accountsIntercept, err := NewAccountsIntercept(bg.AccountsInterceptList, bg.AccountsInterceptResponses)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid accounts intercept for backend group %s: %w", bgName, err)
+		}
+
+		backendGroups[bgName].Override(
+			WithWSClientSideFiltering(bg.WSClientSideFiltering),
+			WithAccountsIntercept(accountsIntercept),
+			WithLocalZone(localZone),
+			WithFailFastOnClientError(bg.FailFastOnClientError),
+			WithConsensusLatencyWeighted(bg.ConsensusLatencyWeighted),
+		)
+		if bg.ShadowBackend != "" {
+			shadow := backendsByName[bg.ShadowBackend]
+			if shadow == nil {
+				return nil, nil, fmt.Errorf("backend %s is not defined as shadow backend for backend group %s", bg.ShadowBackend, bgName)
+			}
+			if slices.Contains(bg.Backends, bg.ShadowBackend) {
+				return nil, nil, fmt.Errorf("shadow backend %s cannot be part of backend group %s", bg.ShadowBackend, bgName)
+			}
+			sampleRate := bg.ShadowSampleRate
+			if sampleRate == 0 {
+				sampleRate = 1
+			}
+			if sampleRate < 0 || sampleRate > 1 {
+				return nil, nil, fmt.Errorf("shadow_sample_rate for backend group %s must be between 0 and 1", bgName)
+			}
+			backendGroups[bgName].Override(WithShadowBackend(shadow, sampleRate, bg.ShadowLogDivergence))
+		}
+		if len(bg.ErrorNormalization) > 0 {
+			normalizer, err := NewErrorNormalizer(bg.ErrorNormalization)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid error normalization for backend group %s: %w", bgName, err)
+			}
+			backendGroups[bgName].Override(WithErrorNormalizer(normalizer))
+		}
+		if len(bg.CanaryRequests) > 0 {
+			if bg.CanaryInterval <= 0 {
+				return nil, nil, fmt.Errorf("canary_interval must be set for backend group %s", bgName)
+			}
+			reqs, err := NewCanaryRequests(bg.CanaryRequests)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid canary requests for backend group %s: %w", bgName, err)
+			}
+			backendGroups[bgName].Override(WithCanary(reqs, time.Duration(bg.CanaryInterval)))
+		}
+		if bg.MaxBatchSize < 0 {
+			return nil, nil, fmt.Errorf("max_batch_size for backend group %s must not be negative", bgName)
+		}
+		if bg.MaxBatchSize > 0 {
+			policy := bg.OnOversize
+			if policy == "" {
+				policy = OversizeReject
+			}
+			if policy != OversizeReject && policy != OversizeSplit {
+				return nil, nil, fmt.Errorf("invalid on_oversize %q for backend group %s, must be reject or split", bg.OnOversize, bgName)
+			}
+			backendGroups[bgName].Override(WithGroupMaxBatchSize(bg.MaxBatchSize, policy))
+		}
+		if err := validateDraining(bgName, bg); err != nil {
+			return nil, nil, err
+		}
+		if err := backendGroups[bgName].SetDrained(drainingBackends(config.BackendGroups)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for bgName, bg := range config.BackendGroups {
+		if bg.FallbackGroup == "" {
+			continue
+		}
+		if bg.FallbackGroup == bgName {
+			return nil, nil, fmt.Errorf("backend group %s cannot be its own fallback group", bgName)
+		}
+		fallbackGroup := backendGroups[bg.FallbackGroup]
+		if fallbackGroup == nil {
+			return nil, nil, fmt.Errorf("fallback group %s of backend group %s is not defined", bg.FallbackGroup, bgName)
+		}
+		backendGroups[bgName].fallbackGroup = fallbackGroup
+		log.Info("configured fallback group", "backend_group", bgName, "fallback_group", bg.FallbackGroup)
+	}
+
+	for bgName, bg := range config.BackendGroups {
+		if bg.ExpectedChainID == 0 {
+			continue
+		}
+		if err := verifyBackendChainIDs(bgName, backendGroups[bgName].Backends, bg.ExpectedChainID, chainIDCheckTimeout(bg)); err != nil {
+			return nil, nil, err
 		}
 	}
 
@@ -297,26 +362,35 @@ func Start(config *Config) (*Server, func(), error) {
 		}
 	}
 
-	var (
-		cache    Cache
-		rpcCache RPCCache
-	)
+	var rpcCache RPCCache
 	if config.Cache.Enabled {
+		ttl := defaultCacheTtl
+		if config.Cache.TTL != 0 {
+			ttl = time.Duration(config.Cache.TTL)
+		}
+		staleTTL := time.Duration(config.Cache.StaleWhileRevalidate)
+		var memoryCache Cache
 		if redisClient == nil {
 			log.Warn("redis is not configured, using in-memory cache")
-			cache = newMemoryCache()
-		} else {
-			ttl := defaultCacheTtl
-			if config.Cache.TTL != 0 {
-				ttl = time.Duration(config.Cache.TTL)
+			memoryCache = newMemoryCache()
+		}
+		newCache := func(ttl time.Duration) Cache {
+			if redisClient == nil {
+				return memoryCache
 			}
-			cache = newRedisCache(redisClient, redisReadClient, config.Redis.Namespace, ttl)
-
+			var cache Cache = newRedisCache(redisClient, redisReadClient, config.Redis.Namespace, ttl)
 			if config.Redis.FallbackToMemory {
 				cache = newFallbackCache(cache, newMemoryCache())
 			}
+			return cache
+		}
+		c := newRPCCache(newCacheWithCompression(newCache(ttl)))
+		if staleTTL > 0 && len(config.Cache.StaleWhileRevalidateMethods) > 0 {
+			// stale entries must outlive the fresh ttl in redis, which only applies to these methods
+			swrCache := newCacheWithCompression(newCache(ttl + staleTTL))
+			c.(*rpcCache).enableStaleWhileRevalidate(swrCache, config.Cache.StaleWhileRevalidateMethods, ttl, staleTTL)
 		}
-		rpcCache = newRPCCache(newCacheWithCompression(cache))
+		rpcCache = c
 	}
 
 	limiterFactory := func(dur time.Duration, max int, prefix string) FrontendRateLimiter {
@@ -357,6 +431,23 @@ func Start(config *Config) (*Server, func(), error) {
 	if err != nil {
 		return nil, nil, fmt.Errorf("error creating server: %w", err)
 	}
+	srv.forwardedHeaders = forwardedHeaders
+	srv.clientTiers = config.ClientTiers
+	srv.logSampleRate = config.Logging.SampleRate
+	if config.AccessLog.Enabled {
+		accessLogger, err := NewAccessLoggerFromConfig(config.AccessLog)
+		if err != nil {
+			return nil, nil, err
+		}
+		srv.accessLogger = accessLogger
+	}
+	srv.healthCheckRequireConsensus = config.Server.HealthCheckRequireConsensus
+	srv.rewriteRequestIDs = config.Server.RewriteRequestIDs
+	srv.maxWSConnsPerClient = config.Server.MaxWSConnsPerClient
+	srv.backendsByName = backendsByName
+	srv.backendConfigs = copyBackendConfigs(config.Backends)
+	srv.backendOptions = config.BackendOptions
+	srv.rpcRequestSemaphore = rpcRequestSemaphore
 
 	// Enable to support browser websocket connections.
 	// See https://pkg.go.dev/github.com/gorilla/websocket#hdr-Origin_Considerations
@@ -411,7 +502,7 @@ func Start(config *Config) (*Server, func(), error) {
 		bgcfg := config.BackendGroups[bgName]
 
 		if !bgcfg.ValidateRoutingStrategy(bgName) {
-			log.Crit("Invalid routing strategy provided. Valid options: fallback, multicall, consensus_aware, \"\"", "name", bgName)
+			log.Crit("Invalid routing strategy provided. Valid options: fallback, multicall, consensus_aware, latency_weighted, \"\"", "name", bgName)
 		}
 
 		log.Info("configuring routing strategy for backend_group", "name", bgName, "routing_strategy", bgcfg.RoutingStrategy)
@@ -436,9 +527,21 @@ func Start(config *Config) (*Server, func(), error) {
 			if bgcfg.ConsensusMinPeerCount > 0 {
 				copts = append(copts, WithMinPeerCount(uint64(bgcfg.ConsensusMinPeerCount)))
 			}
+			if bgcfg.ConsensusQuorum > 0 {
+				copts = append(copts, WithQuorum(bgcfg.ConsensusQuorum))
+			}
+			if bgcfg.ConsensusEvictionThreshold > 0 {
+				copts = append(copts, WithEvictionThreshold(bgcfg.ConsensusEvictionThreshold))
+			}
+			if bgcfg.ConsensusReadmissionThreshold > 0 {
+				copts = append(copts, WithReadmissionThreshold(bgcfg.ConsensusReadmissionThreshold))
+			}
 			if bgcfg.ConsensusMaxBlockRange > 0 {
 				copts = append(copts, WithMaxBlockRange(bgcfg.ConsensusMaxBlockRange))
 			}
+			if bgcfg.MaxBlockDepth > 0 {
+				copts = append(copts, WithMaxBlockDepth(bgcfg.MaxBlockDepth))
+			}
 			if bgcfg.ConsensusPollerInterval > 0 {
 				copts = append(copts, WithPollerInterval(time.Duration(bgcfg.ConsensusPollerInterval)))
 			}
@@ -488,6 +591,13 @@ func Start(config *Config) (*Server, func(), error) {
 	<-errTimer.C
 	log.Info("started proxyd")
 
+	for _, back := range backendsByName {
+		back.StartHealthCheck()
+	}
+	for _, bg := range backendGroups {
+		bg.StartCanary()
+	}
+
 	shutdownFunc := func() {
 		log.Info("shutting down proxyd")
 		srv.Shutdown()
@@ -497,6 +607,204 @@ func Start(config *Config) (*Server, func(), error) {
 	return srv, shutdownFunc, nil
 }
 
+// newBackendFromConfig creates a backend from its config section and the global backend options
+func newBackendFromConfig(name string, cfg *BackendConfig, options BackendOptions, rpcRequestSemaphore *semaphore.Weighted) (*Backend, error) {
+	opts := make([]BackendOpt, 0)
+
+	rpcURL, err := ReadFromEnvOrConfig(cfg.RPCURL)
+	if err != nil {
+		return nil, err
+	}
+	wsURL, err := ReadFromEnvOrConfig(cfg.WSURL)
+	if err != nil {
+		return nil, err
+	}
+	if rpcURL == "" {
+		return nil, fmt.Errorf("must define an RPC URL for backend %s", name)
+	}
+
+	if options.ResponseTimeoutSeconds != 0 {
+		timeout := secondsToDuration(options.ResponseTimeoutSeconds)
+		opts = append(opts, WithTimeout(timeout))
+	}
+	if options.MaxRetries != 0 {
+		opts = append(opts, WithMaxRetries(options.MaxRetries))
+	}
+	if options.MaxResponseSizeBytes != 0 {
+		opts = append(opts, WithMaxResponseSize(options.MaxResponseSizeBytes))
+	}
+	if len(options.MethodMaxResponseSizeBytes) > 0 {
+		opts = append(opts, WithMethodMaxResponseSize(options.MethodMaxResponseSizeBytes))
+	}
+	if options.OutOfServiceSeconds != 0 {
+		opts = append(opts, WithOutOfServiceDuration(secondsToDuration(options.OutOfServiceSeconds)))
+	}
+	if options.MaxDegradedLatencyThreshold > 0 {
+		opts = append(opts, WithMaxDegradedLatencyThreshold(time.Duration(options.MaxDegradedLatencyThreshold)))
+	}
+	if options.MaxLatencyThreshold > 0 {
+		opts = append(opts, WithMaxLatencyThreshold(time.Duration(options.MaxLatencyThreshold)))
+	}
+	if options.MaxErrorRateThreshold > 0 {
+		opts = append(opts, WithMaxErrorRateThreshold(options.MaxErrorRateThreshold))
+	}
+	if options.NormalizeJSONRPCVersion {
+		opts = append(opts, WithNormalizeJSONRPCVersion(true))
+	}
+	if options.ValidateResponseIDs {
+		opts = append(opts, WithValidateResponseIDs(true))
+	}
+	if options.SlowRequestThreshold > 0 {
+		opts = append(opts, WithSlowRequestThreshold(time.Duration(options.SlowRequestThreshold)))
+	}
+	if options.UnhealthyAfter > 0 || options.HealthyAfter > 0 {
+		opts = append(opts, WithHealthHysteresis(time.Duration(options.UnhealthyAfter), time.Duration(options.HealthyAfter)))
+	}
+	if len(options.NonRetryableErrors) > 0 || len(options.NonRetryableErrorCodes) > 0 {
+		opts = append(opts, WithNonRetryableErrors(options.NonRetryableErrors, options.NonRetryableErrorCodes))
+	}
+	if cfg.MaxRPS != 0 {
+		opts = append(opts, WithMaxRPS(cfg.MaxRPS))
+	}
+	if cfg.MaxWSConns != 0 {
+		opts = append(opts, WithMaxWSConns(cfg.MaxWSConns))
+	}
+	if cfg.MaxBatchSize != 0 {
+		opts = append(opts, WithMaxBatchSize(cfg.MaxBatchSize))
+	}
+	if cfg.BatchParallelism != 0 {
+		opts = append(opts, WithBatchParallelism(cfg.BatchParallelism))
+	}
+	if cfg.Password != "" {
+		passwordVal, err := ReadFromEnvOrConfig(cfg.Password)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithBasicAuth(cfg.Username, passwordVal))
+	}
+
+	headers := map[string]string{}
+	for headerName, headerValue := range cfg.Headers {
+		headerValue, err := ReadFromEnvOrConfig(headerValue)
+		if err != nil {
+			return nil, err
+		}
+
+		headers[headerName] = headerValue
+	}
+	opts = append(opts, WithHeaders(headers))
+
+	tlsConfig, err := configureBackendTLS(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		log.Info("using custom TLS config for backend", "name", name)
+		opts = append(opts, WithTLSConfig(tlsConfig))
+	}
+	if len(cfg.TLSCertificatePins) > 0 {
+		pins := make([]string, 0, len(cfg.TLSCertificatePins))
+		for _, pin := range cfg.TLSCertificatePins {
+			normalized, err := NormalizeCertificateFingerprint(pin)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tls_certificate_pins for backend %s: %w", name, err)
+			}
+			pins = append(pins, normalized)
+		}
+		log.Info("pinning TLS certificates for backend", "name", name, "pins", len(pins))
+		opts = append(opts, WithTLSCertificatePin(pins))
+	}
+	if cfg.MaxIdleConnsPerHost != 0 || cfg.IdleConnTimeout != 0 || cfg.KeepAlive != 0 || cfg.DisableKeepAlives {
+		if cfg.MaxIdleConnsPerHost < 0 || cfg.IdleConnTimeout < 0 {
+			return nil, fmt.Errorf("max_idle_conns_per_host and idle_conn_timeout of backend %s must not be negative", name)
+		}
+		opts = append(opts, WithTransportConfig(TransportConfig{
+			MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+			IdleConnTimeout:     time.Duration(cfg.IdleConnTimeout),
+			KeepAlive:           time.Duration(cfg.KeepAlive),
+			DisableKeepAlives:   cfg.DisableKeepAlives,
+		}))
+	}
+	if cfg.StripTrailingXFF {
+		opts = append(opts, WithStrippedTrailingXFF())
+	}
+	if cfg.Zone != "" {
+		opts = append(opts, WithZone(cfg.Zone))
+	}
+	if cfg.RequestIDHeader != "" {
+		opts = append(opts, WithRequestIDHeader(cfg.RequestIDHeader))
+		opts = append(opts, WithPassthroughClientID(cfg.PassthroughClientID))
+	}
+	if cfg.HealthCheckInterval > 0 {
+		var expected json.RawMessage
+		if cfg.HealthCheckExpectedResult != "" {
+			if !json.Valid([]byte(cfg.HealthCheckExpectedResult)) {
+				return nil, fmt.Errorf("health_check_expected_result of backend %s must be valid JSON", name)
+			}
+			expected = json.RawMessage(cfg.HealthCheckExpectedResult)
+		}
+		opts = append(opts, WithHealthCheck(cfg.HealthCheckMethod, expected, time.Duration(cfg.HealthCheckInterval)))
+	}
+	opts = append(opts, WithProxydIP(os.Getenv("PROXYD_IP")))
+	opts = append(opts, WithConsensusSkipPeerCountCheck(cfg.ConsensusSkipPeerCountCheck))
+	opts = append(opts, WithConsensusForcedCandidate(cfg.ConsensusForcedCandidate))
+	opts = append(opts, WithWeight(cfg.Weight))
+
+	receiptsTarget, err := ReadFromEnvOrConfig(cfg.ConsensusReceiptsTarget)
+	if err != nil {
+		return nil, err
+	}
+	receiptsTarget, err = validateReceiptsTarget(receiptsTarget)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, WithConsensusReceiptTarget(receiptsTarget))
+
+	return NewBackend(name, rpcURL, wsURL, rpcRequestSemaphore, opts...), nil
+}
+
+// verifyBackendChainIDs checks in parallel that every given backend of the group serves the expected
+// chain. Backends that cannot be reached within the timeout are logged and skipped so a slow
+// backend does not block startup or a reload, but a backend reporting a different chain id is fatal.
+func verifyBackendChainIDs(bgName string, backends []*Backend, expected uint64, timeout time.Duration) error {
+	g, gctx := errgroup.WithContext(context.Background())
+	for _, be := range backends {
+		be := be
+		g.Go(func() error {
+			ctx, cancel := context.WithTimeout(gctx, timeout)
+			defer cancel()
+			chainID, err := be.FetchChainID(ctx)
+			if err != nil {
+				log.Error("unable to verify backend chain id",
+					"backend_group", bgName,
+					"backend_name", be.Name,
+					"err", err,
+				)
+				return nil
+			}
+			if chainID != expected {
+				log.Error("backend chain id does not match the expected chain id of its group",
+					"backend_group", bgName,
+					"backend_name", be.Name,
+					"expected_chain_id", expected,
+					"chain_id", chainID,
+				)
+				return fmt.Errorf("backend %s in group %s reports chain id %d, expected %d", be.Name, bgName, chainID, expected)
+			}
+			log.Info("verified backend chain id", "backend_group", bgName, "backend_name", be.Name, "chain_id", chainID)
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+func chainIDCheckTimeout(bgcfg *BackendGroupConfig) time.Duration {
+	if bgcfg.ChainIDCheckTimeout > 0 {
+		return time.Duration(bgcfg.ChainIDCheckTimeout)
+	}
+	return defaultChainIDCheckTimeout
+}
+
 func validateReceiptsTarget(val string) (string, error) {
 	if val == "" {
 		val = ReceiptsTargetDebugGetRawReceipts
@@ -512,6 +820,40 @@ func validateReceiptsTarget(val string) (string, error) {
 	}
 }
 
+// unforwardableHeaders are hop-by-hop, auth and proxyd managed headers that must never be
+// copied from a client request to a backend
+var unforwardableHeaders = map[string]bool{
+	"Connection":               true,
+	"Keep-Alive":               true,
+	"Proxy-Authenticate":       true,
+	"Proxy-Authorization":      true,
+	"Proxy-Connection":         true,
+	"Te":                       true,
+	"Trailer":                  true,
+	"Transfer-Encoding":        true,
+	"Upgrade":                  true,
+	"Authorization":            true,
+	"Cookie":                   true,
+	"Host":                     true,
+	"Content-Length":           true,
+	"Content-Type":             true,
+	"X-Forwarded-For":          true,
+	DefaultOpTxProxyAuthHeader: true,
+}
+
+// validateForwardedHeaders returns the canonical form of the configured forwarded headers
+func validateForwardedHeaders(names []string) ([]string, error) {
+	canonical := make([]string, 0, len(names))
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		if unforwardableHeaders[name] {
+			return nil, fmt.Errorf("header %s cannot be forwarded to backends", name)
+		}
+		canonical = append(canonical, name)
+	}
+	return canonical, nil
+}
+
 func secondsToDuration(seconds int) time.Duration {
 	return time.Duration(seconds) * time.Second
 }
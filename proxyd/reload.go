@@ -0,0 +1,242 @@
+package proxyd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ReloadOnSignal reloads the backends from the config returned by loadConfig whenever the
+// process receives SIGHUP, until the returned function is called. Errors are logged and the
+// running configuration is kept.
+func (s *Server) ReloadOnSignal(loadConfig func() (*Config, error)) (stop func()) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sig:
+				log.Info("caught SIGHUP, reloading configuration")
+				config, err := loadConfig()
+				if err != nil {
+					log.Error("error reading config file, keeping current configuration", "err", err)
+					continue
+				}
+				if _, _, err := s.ReloadBackends(config); err != nil {
+					log.Error("error reloading configuration, keeping current configuration", "err", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
+
+// ReloadBackends applies the backends of a freshly read config to the running server.
+// New backends are added to the groups that list them, removed backends are drained, and
+// backends whose config changed are replaced. Requests already in flight are not interrupted.
+// Backend groups themselves are not added or removed. Changes to the backends of a consensus
+// aware group are rejected since its poller tracks per-backend state, and backends added to a
+// group with an expected chain id are verified before they are swapped in.
+func (s *Server) ReloadBackends(config *Config) (added int, removed int, err error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	for bgName, bgcfg := range config.BackendGroups {
+		for _, bName := range bgcfg.Backends {
+			if config.Backends[bName] == nil {
+				return 0, 0, fmt.Errorf("backend %s of backend group %s is not defined", bName, bgName)
+			}
+		}
+		if err := validateDraining(bgName, bgcfg); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	changed := make(map[string]bool)
+	for name, cfg := range config.Backends {
+		if old, ok := s.backendConfigs[name]; !ok || !reflect.DeepEqual(old, *cfg) {
+			changed[name] = true
+		}
+	}
+	deleted := make(map[string]bool)
+	for name := range s.backendConfigs {
+		if config.Backends[name] == nil {
+			deleted[name] = true
+		}
+	}
+
+	// work out every group change before building anything so that a rejected reload leaves
+	// the server untouched
+	toRemove := make(map[string][]string)
+	toAdd := make(map[string][]string)
+	for bgName, bg := range s.BackendGroups {
+		bgcfg, ok := config.BackendGroups[bgName]
+		if !ok {
+			continue
+		}
+		toRemove[bgName], toAdd[bgName] = backendGroupChanges(bg, bgcfg, changed)
+		if bg.Consensus != nil && (len(toRemove[bgName]) > 0 || len(toAdd[bgName]) > 0) {
+			return 0, 0, fmt.Errorf("backends of consensus aware backend group %s can't be reloaded, a restart is required", bgName)
+		}
+	}
+
+	replaced := make(map[string]*Backend, len(changed))
+	for name := range changed {
+		back, err := newBackendFromConfig(name, config.Backends[name], s.backendOptions, s.rpcRequestSemaphore)
+		if err != nil {
+			return 0, 0, err
+		}
+		replaced[name] = back
+	}
+
+	backendsByName := make(map[string]*Backend, len(config.Backends))
+	for name := range config.Backends {
+		if back, ok := replaced[name]; ok {
+			backendsByName[name] = back
+		} else {
+			backendsByName[name] = s.backendsByName[name]
+		}
+	}
+
+	for bgName, names := range toAdd {
+		bgcfg := config.BackendGroups[bgName]
+		if bgcfg.ExpectedChainID == 0 || len(names) == 0 {
+			continue
+		}
+		backends := make([]*Backend, 0, len(names))
+		for _, name := range names {
+			backends = append(backends, backendsByName[name])
+		}
+		if err := verifyBackendChainIDs(bgName, backends, bgcfg.ExpectedChainID, chainIDCheckTimeout(bgcfg)); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	draining := drainingBackends(config.BackendGroups)
+	for bgName, bg := range s.BackendGroups {
+		bgcfg, ok := config.BackendGroups[bgName]
+		if !ok {
+			log.Warn("backend group removed from config, not reloading it", "backend_group", bgName)
+			continue
+		}
+		reloadBackendGroup(bg, bgcfg, toRemove[bgName], toAdd[bgName], backendsByName)
+		if err := bg.SetDrained(draining); err != nil {
+			log.Warn("error applying draining backends", "backend_group", bgName, "err", err)
+		}
+	}
+	for bgName := range config.BackendGroups {
+		if s.BackendGroups[bgName] == nil {
+			log.Warn("backend group added to config, a restart is required to use it", "backend_group", bgName)
+		}
+	}
+
+	// backends kept by a group that wasn't reloaded must keep serving
+	inUse := make(map[*Backend]bool)
+	for _, bg := range s.BackendGroups {
+		backends, _ := bg.backends()
+		for _, be := range backends {
+			inUse[be] = true
+		}
+	}
+	for name, back := range s.backendsByName {
+		if deleted[name] || replaced[name] != nil {
+			if !inUse[back] {
+				back.Drain()
+			}
+			removed++
+		}
+	}
+	added = len(replaced)
+	for _, back := range replaced {
+		back.StartHealthCheck()
+	}
+
+	s.backendsByName = backendsByName
+	s.backendConfigs = copyBackendConfigs(config.Backends)
+
+	log.Info(fmt.Sprintf("Configuration reloaded: +%d backends, -%d backends", added, removed))
+	return added, removed, nil
+}
+
+// backendGroupChanges returns the backends to remove from and add to bg to bring it in line
+// with its new config, where changed backends are both removed and added back
+func backendGroupChanges(bg *BackendGroup, bgcfg *BackendGroupConfig, changed map[string]bool) (toRemove []string, toAdd []string) {
+	current, _ := bg.backends()
+
+	wanted := make(map[string]bool, len(bgcfg.Backends))
+	for _, name := range bgcfg.Backends {
+		wanted[name] = true
+	}
+	present := make(map[string]bool, len(current))
+	var toRemove []string
+	for _, be := range current {
+		present[be.Name] = true
+		if !wanted[be.Name] || changed[be.Name] {
+			toRemove = append(toRemove, be.Name)
+		}
+	}
+	for _, name := range bgcfg.Backends {
+		if !present[name] || changed[name] {
+			toAdd = append(toAdd, name)
+		}
+	}
+	return toRemove, toAdd
+}
+
+// reloadBackendGroup removes and adds the given backends of bg
+func reloadBackendGroup(bg *BackendGroup, bgcfg *BackendGroupConfig, toRemove []string, toAdd []string, backendsByName map[string]*Backend) {
+	fallbacks := make(map[string]bool, len(bgcfg.Fallbacks))
+	for _, name := range bgcfg.Fallbacks {
+		fallbacks[name] = true
+	}
+	for _, name := range toRemove {
+		bg.RemoveBackend(name)
+		log.Info("removed backend from backend group", "backend_name", name, "backend_group", bg.Name)
+	}
+	for _, name := range toAdd {
+		bg.AddBackend(backendsByName[name], fallbacks[name])
+		log.Info("added backend to backend group", "backend_name", name, "backend_group", bg.Name)
+	}
+}
+
+// validateDraining checks that the draining backends are part of the group
+func validateDraining(bgName string, bgcfg *BackendGroupConfig) error {
+	for _, name := range bgcfg.Draining {
+		if !slices.Contains(bgcfg.Backends, name) {
+			return fmt.Errorf("draining backend %s is not part of backend group %s", name, bgName)
+		}
+	}
+	return nil
+}
+
+// drainingBackends returns the backends drained by any backend group
+func drainingBackends(groups BackendGroupsConfig) []string {
+	var names []string
+	for _, bgcfg := range groups {
+		for _, name := range bgcfg.Draining {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func copyBackendConfigs(backends BackendsConfig) map[string]BackendConfig {
+	configs := make(map[string]BackendConfig, len(backends))
+	for name, cfg := range backends {
+		configs[name] = *cfg
+	}
+	return configs
+}
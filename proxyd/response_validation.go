@@ -0,0 +1,153 @@
+package proxyd
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type ResponseValidationMode string
+
+const (
+	// ResponseValidationStrict treats an invalid response as a backend failure and tries the next backend
+	ResponseValidationStrict ResponseValidationMode = "strict"
+	// ResponseValidationPermissive logs invalid responses but still passes them through to the client
+	ResponseValidationPermissive ResponseValidationMode = "permissive"
+)
+
+const (
+	ValidationRuleBlockNumber   = "block_number"
+	ValidationRuleBlockHash     = "block_hash"
+	ValidationRuleReceiptStatus = "receipt_status"
+)
+
+// ResponseValidationRule checks the result of a successful response for a single method
+type ResponseValidationRule interface {
+	Name() string
+	Method() string
+	Validate(result interface{}) error
+}
+
+// ValidationRuleSet holds the response validation rules of a backend group, indexed by method
+type ValidationRuleSet struct {
+	mode  ResponseValidationMode
+	rules map[string][]ResponseValidationRule
+}
+
+var builtinValidationRules = map[string]ResponseValidationRule{
+	ValidationRuleBlockNumber: &resultFieldRule{
+		name:   ValidationRuleBlockNumber,
+		method: "eth_getBlockByNumber",
+		field:  "number",
+		check:  validateHexQuantity,
+	},
+	ValidationRuleBlockHash: &resultFieldRule{
+		name:   ValidationRuleBlockHash,
+		method: "eth_getBlockByNumber",
+		field:  "hash",
+		check:  validateHash,
+	},
+	ValidationRuleReceiptStatus: &resultFieldRule{
+		name:   ValidationRuleReceiptStatus,
+		method: "eth_getTransactionReceipt",
+		field:  "status",
+		check:  validateReceiptStatus,
+	},
+}
+
+// NewValidationRuleSet builds a rule set from config. If no rules are listed, all built-in rules are used.
+func NewValidationRuleSet(cfg ResponseValidationConfig) (*ValidationRuleSet, error) {
+	switch cfg.Mode {
+	case ResponseValidationStrict, ResponseValidationPermissive:
+	default:
+		return nil, fmt.Errorf("invalid response validation mode: %s", cfg.Mode)
+	}
+
+	names := cfg.Rules
+	if len(names) == 0 {
+		names = []string{ValidationRuleBlockNumber, ValidationRuleBlockHash, ValidationRuleReceiptStatus}
+	}
+
+	rs := &ValidationRuleSet{
+		mode:  cfg.Mode,
+		rules: make(map[string][]ResponseValidationRule),
+	}
+	for _, name := range names {
+		rule, ok := builtinValidationRules[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown response validation rule: %s", name)
+		}
+		rs.rules[rule.Method()] = append(rs.rules[rule.Method()], rule)
+	}
+	return rs, nil
+}
+
+func (rs *ValidationRuleSet) Mode() ResponseValidationMode {
+	return rs.mode
+}
+
+// Validate checks every non-error response against the rules for its method.
+// It returns the method and name of the first rule that failed.
+func (rs *ValidationRuleSet) Validate(reqs []*RPCReq, res []*RPCRes) (method string, rule string, err error) {
+	for i, r := range res {
+		if i >= len(reqs) || r.IsError() || r.Result == nil {
+			continue
+		}
+		for _, vr := range rs.rules[reqs[i].Method] {
+			if err := vr.Validate(r.Result); err != nil {
+				return reqs[i].Method, vr.Name(), err
+			}
+		}
+	}
+	return "", "", nil
+}
+
+// resultFieldRule validates a single string field of an object result
+type resultFieldRule struct {
+	name   string
+	method string
+	field  string
+	check  func(string) error
+}
+
+func (r *resultFieldRule) Name() string   { return r.name }
+func (r *resultFieldRule) Method() string { return r.method }
+
+func (r *resultFieldRule) Validate(result interface{}) error {
+	obj, ok := result.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("result is not an object")
+	}
+	val, ok := obj[r.field].(string)
+	if !ok {
+		return fmt.Errorf("result.%s is missing or not a string", r.field)
+	}
+	if err := r.check(val); err != nil {
+		return fmt.Errorf("result.%s: %w", r.field, err)
+	}
+	return nil
+}
+
+func validateHexQuantity(val string) error {
+	_, err := hexutil.DecodeBig(val)
+	return err
+}
+
+func validateHash(val string) error {
+	b, err := hexutil.Decode(val)
+	if err != nil {
+		return err
+	}
+	if len(b) != common.HashLength {
+		return fmt.Errorf("expected %d bytes, got %d", common.HashLength, len(b))
+	}
+	return nil
+}
+
+func validateReceiptStatus(val string) error {
+	if val != "0x0" && val != "0x1" {
+		return fmt.Errorf("unexpected status %q", val)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package proxyd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationRuleSet(t *testing.T) {
+	rs, err := NewValidationRuleSet(ResponseValidationConfig{Mode: ResponseValidationStrict})
+	require.NoError(t, err)
+
+	validHash := "0x2a2ad3e6a5ee6a87a1a5b2d5e2e6e7d3fcdc2a7a8e8b1e0c0f3d5a6b7c8d9e0f"
+
+	tests := []struct {
+		name     string
+		method   string
+		result   string
+		wantRule string
+	}{
+		{"valid block", "eth_getBlockByNumber", `{"number":"0x10","hash":"` + validHash + `"}`, ""},
+		{"null block", "eth_getBlockByNumber", `null`, ""},
+		{"invalid block number", "eth_getBlockByNumber", `{"number":"sixteen","hash":"` + validHash + `"}`, ValidationRuleBlockNumber},
+		{"missing block number", "eth_getBlockByNumber", `{"hash":"` + validHash + `"}`, ValidationRuleBlockNumber},
+		{"short block hash", "eth_getBlockByNumber", `{"number":"0x10","hash":"0x1234"}`, ValidationRuleBlockHash},
+		{"valid receipt", "eth_getTransactionReceipt", `{"status":"0x1"}`, ""},
+		{"invalid receipt status", "eth_getTransactionReceipt", `{"status":"0x2"}`, ValidationRuleReceiptStatus},
+		{"unvalidated method", "eth_chainId", `"0x1"`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.result), &result))
+			reqs := []*RPCReq{{JSONRPC: JSONRPCVersion, Method: tt.method, ID: []byte("1")}}
+			res := []*RPCRes{NewRPCRes([]byte("1"), result)}
+
+			method, rule, err := rs.Validate(reqs, res)
+			require.Equal(t, tt.wantRule, rule)
+			if tt.wantRule == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.method, method)
+			}
+		})
+	}
+}
+
+func TestValidationRuleSetConfig(t *testing.T) {
+	_, err := NewValidationRuleSet(ResponseValidationConfig{Mode: "lenient"})
+	require.Error(t, err)
+
+	_, err = NewValidationRuleSet(ResponseValidationConfig{Mode: ResponseValidationPermissive, Rules: []string{"unknown"}})
+	require.Error(t, err)
+
+	rs, err := NewValidationRuleSet(ResponseValidationConfig{Mode: ResponseValidationPermissive, Rules: []string{ValidationRuleReceiptStatus}})
+	require.NoError(t, err)
+	require.Equal(t, ResponseValidationPermissive, rs.Mode())
+	require.Len(t, rs.rules, 1)
+}
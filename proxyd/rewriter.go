@@ -13,6 +13,8 @@ type RewriteContext struct {
 	safe          hexutil.Uint64
 	finalized     hexutil.Uint64
 	maxBlockRange uint64
+	// maxBlockDepth rejects blocks older than latest - maxBlockDepth, disabled if 0
+	maxBlockDepth uint64
 }
 
 type RewriteResult uint8
@@ -34,6 +36,7 @@ const (
 var (
 	ErrRewriteBlockOutOfRange = errors.New("block is out of range")
 	ErrRewriteRangeTooLarge   = errors.New("block range is too large")
+	ErrRewriteBlockTooOld     = errors.New("block is older than the max block depth")
 )
 
 // RewriteTags modifies the request and the response based on block tags
@@ -251,6 +254,13 @@ func remarshalBlockNumberOrHash(current interface{}) (*rpc.BlockNumberOrHash, er
 	return &bnh, nil
 }
 
+// tooOld returns whether the block is older than the max block depth allows
+func (rctx RewriteContext) tooOld(block uint64) bool {
+	return rctx.maxBlockDepth > 0 &&
+		uint64(rctx.latest) > rctx.maxBlockDepth &&
+		block < uint64(rctx.latest)-rctx.maxBlockDepth
+}
+
 func rewriteTag(rctx RewriteContext, current string) (string, bool, error) {
 	bnh, err := remarshalBlockNumberOrHash(current)
 	if err != nil {
@@ -263,8 +273,12 @@ func rewriteTag(rctx RewriteContext, current string) (string, bool, error) {
 	}
 
 	switch *bnh.BlockNumber {
-	case rpc.PendingBlockNumber,
-		rpc.EarliestBlockNumber:
+	case rpc.PendingBlockNumber:
+		return current, false, nil
+	case rpc.EarliestBlockNumber:
+		if rctx.tooOld(0) {
+			return "", false, ErrRewriteBlockTooOld
+		}
 		return current, false, nil
 	case rpc.FinalizedBlockNumber:
 		return rctx.finalized.String(), true, nil
@@ -276,6 +290,9 @@ func rewriteTag(rctx RewriteContext, current string) (string, bool, error) {
 		if bnh.BlockNumber.Int64() > int64(rctx.latest) {
 			return "", false, ErrRewriteBlockOutOfRange
 		}
+		if rctx.tooOld(uint64(bnh.BlockNumber.Int64())) {
+			return "", false, ErrRewriteBlockTooOld
+		}
 	}
 
 	return current, false, nil
@@ -288,8 +305,12 @@ func rewriteTagBlockNumberOrHash(rctx RewriteContext, current *rpc.BlockNumberOr
 	}
 
 	switch *current.BlockNumber {
-	case rpc.PendingBlockNumber,
-		rpc.EarliestBlockNumber:
+	case rpc.PendingBlockNumber:
+		return current, false, nil
+	case rpc.EarliestBlockNumber:
+		if rctx.tooOld(0) {
+			return nil, false, ErrRewriteBlockTooOld
+		}
 		return current, false, nil
 	case rpc.FinalizedBlockNumber:
 		bn := rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(rctx.finalized))
@@ -304,6 +325,9 @@ func rewriteTagBlockNumberOrHash(rctx RewriteContext, current *rpc.BlockNumberOr
 		if current.BlockNumber.Int64() > int64(rctx.latest) {
 			return nil, false, ErrRewriteBlockOutOfRange
 		}
+		if rctx.tooOld(uint64(current.BlockNumber.Int64())) {
+			return nil, false, ErrRewriteBlockTooOld
+		}
 	}
 
 	return current, false, nil
@@ -609,6 +609,82 @@ func TestRewriteRequest(t *testing.T) {
 			expected:    RewriteOverrideError,
 			expectedErr: ErrRewriteBlockOutOfRange,
 		},
+		/* max block depth */
+		{
+			name: "eth_getBlockByNumber block older than max depth",
+			args: args{
+				rctx: RewriteContext{latest: hexutil.Uint64(100), maxBlockDepth: 10},
+				req:  &RPCReq{Method: "eth_getBlockByNumber", Params: mustMarshalJSON([]string{hexutil.Uint64(50).String()})},
+				res:  nil,
+			},
+			expected:    RewriteOverrideError,
+			expectedErr: ErrRewriteBlockTooOld,
+		},
+		{
+			name: "eth_getBlockByNumber block within max depth",
+			args: args{
+				rctx: RewriteContext{latest: hexutil.Uint64(100), maxBlockDepth: 10},
+				req:  &RPCReq{Method: "eth_getBlockByNumber", Params: mustMarshalJSON([]string{hexutil.Uint64(90).String()})},
+				res:  nil,
+			},
+			expected: RewriteNone,
+		},
+		{
+			name: "eth_getBlockByNumber earliest older than max depth",
+			args: args{
+				rctx: RewriteContext{latest: hexutil.Uint64(100), maxBlockDepth: 10},
+				req:  &RPCReq{Method: "eth_getBlockByNumber", Params: mustMarshalJSON([]string{"earliest"})},
+				res:  nil,
+			},
+			expected:    RewriteOverrideError,
+			expectedErr: ErrRewriteBlockTooOld,
+		},
+		{
+			name: "eth_getBlockByNumber max depth beyond genesis",
+			args: args{
+				rctx: RewriteContext{latest: hexutil.Uint64(100), maxBlockDepth: 1000},
+				req:  &RPCReq{Method: "eth_getBlockByNumber", Params: mustMarshalJSON([]string{"earliest"})},
+				res:  nil,
+			},
+			expected: RewriteNone,
+		},
+		{
+			name: "eth_getCode block hash with max depth",
+			args: args{
+				rctx: RewriteContext{latest: hexutil.Uint64(100), maxBlockDepth: 10},
+				req: &RPCReq{Method: "eth_getCode", Params: mustMarshalJSON([]interface{}{
+					"0x123",
+					map[string]interface{}{
+						"blockHash": "0xc6ef2fc5426d6ad6fd9e2a26abeab0aa2411b7ab17f30a99d3cb96aed1d1055b",
+					}})},
+				res: nil,
+			},
+			expected: RewriteNone,
+		},
+		{
+			name: "eth_getCode block number older than max depth",
+			args: args{
+				rctx: RewriteContext{latest: hexutil.Uint64(100), maxBlockDepth: 10},
+				req: &RPCReq{Method: "eth_getCode", Params: mustMarshalJSON([]interface{}{
+					"0x123",
+					map[string]interface{}{
+						"blockNumber": hexutil.Uint64(50).String(),
+					}})},
+				res: nil,
+			},
+			expected:    RewriteOverrideError,
+			expectedErr: ErrRewriteBlockTooOld,
+		},
+		{
+			name: "eth_getLogs fromBlock older than max depth",
+			args: args{
+				rctx: RewriteContext{latest: hexutil.Uint64(100), maxBlockDepth: 10},
+				req:  &RPCReq{Method: "eth_getLogs", Params: mustMarshalJSON([]map[string]interface{}{{"fromBlock": hexutil.Uint64(50).String(), "toBlock": "latest"}})},
+				res:  nil,
+			},
+			expected:    RewriteOverrideError,
+			expectedErr: ErrRewriteBlockTooOld,
+		},
 	}
 
 	// generalize tests for other methods with same interface and behavior
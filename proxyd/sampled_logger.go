@@ -0,0 +1,72 @@
+package proxyd
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// SampledLogger wraps a log.Logger so that Trace, Debug and Info messages are only logged for
+// requests that were sampled in. Warn, Error and Crit messages are always logged.
+type SampledLogger struct {
+	logger  log.Logger
+	sampled bool
+}
+
+func NewSampledLogger(logger log.Logger, sampled bool) *SampledLogger {
+	return &SampledLogger{
+		logger:  logger,
+		sampled: sampled,
+	}
+}
+
+func (l *SampledLogger) Trace(msg string, ctx ...interface{}) {
+	if l.sampled {
+		l.logger.Trace(msg, ctx...)
+	}
+}
+
+func (l *SampledLogger) Debug(msg string, ctx ...interface{}) {
+	if l.sampled {
+		l.logger.Debug(msg, ctx...)
+	}
+}
+
+func (l *SampledLogger) Info(msg string, ctx ...interface{}) {
+	if l.sampled {
+		l.logger.Info(msg, ctx...)
+	}
+}
+
+func (l *SampledLogger) Warn(msg string, ctx ...interface{}) {
+	l.logger.Warn(msg, ctx...)
+}
+
+func (l *SampledLogger) Error(msg string, ctx ...interface{}) {
+	l.logger.Error(msg, ctx...)
+}
+
+func (l *SampledLogger) Crit(msg string, ctx ...interface{}) {
+	l.logger.Crit(msg, ctx...)
+}
+
+// sampleLogs decides whether the logs of a request are sampled in
+func sampleLogs(rate float64) bool {
+	sampled := rand.Float64() < rate
+	RecordLogSample(sampled)
+	return sampled
+}
+
+// GetLogger returns the logger of a request. When log sampling is enabled, requests that were
+// sampled in log with a sampled=true field, and the others only log warnings and errors.
+func GetLogger(ctx context.Context) *SampledLogger {
+	sampled, ok := ctx.Value(ContextKeyLogSampled).(bool)
+	if !ok {
+		return NewSampledLogger(log.Root(), true)
+	}
+	if sampled {
+		return NewSampledLogger(log.Root().With("sampled", true), true)
+	}
+	return NewSampledLogger(log.Root(), false)
+}
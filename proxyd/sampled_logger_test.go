@@ -0,0 +1,70 @@
+package proxyd
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"math"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleLogsConvergesToRate(t *testing.T) {
+	const n = 100000
+	for _, rate := range []float64{0.01, 0.1, 0.5} {
+		sampled := 0
+		for i := 0; i < n; i++ {
+			if sampleLogs(rate) {
+				sampled++
+			}
+		}
+		// well within 5 standard deviations of the binomial distribution
+		stddev := math.Sqrt(n * rate * (1 - rate))
+		require.InDelta(t, rate*n, float64(sampled), 5*stddev, "rate %v", rate)
+	}
+
+	for i := 0; i < 1000; i++ {
+		require.True(t, sampleLogs(1))
+	}
+}
+
+func TestSampledLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	t.Run("sampled out only logs warnings and errors", func(t *testing.T) {
+		buf.Reset()
+		l := NewSampledLogger(logger, false)
+		l.Debug("debug message")
+		l.Info("info message")
+		l.Warn("warn message")
+		l.Error("error message")
+
+		require.NotContains(t, buf.String(), "debug message")
+		require.NotContains(t, buf.String(), "info message")
+		require.Contains(t, buf.String(), "warn message")
+		require.Contains(t, buf.String(), "error message")
+	})
+
+	t.Run("sampled in logs everything", func(t *testing.T) {
+		buf.Reset()
+		l := NewSampledLogger(logger, true)
+		l.Debug("debug message")
+		l.Info("info message")
+
+		require.Contains(t, buf.String(), "debug message")
+		require.Contains(t, buf.String(), "info message")
+	})
+}
+
+func TestGetLogger(t *testing.T) {
+	require.True(t, GetLogger(context.Background()).sampled)
+
+	ctx := context.WithValue(context.Background(), ContextKeyLogSampled, false) // nolint:staticcheck
+	require.False(t, GetLogger(ctx).sampled)
+
+	ctx = context.WithValue(context.Background(), ContextKeyLogSampled, true) // nolint:staticcheck
+	require.True(t, GetLogger(ctx).sampled)
+}
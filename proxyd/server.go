@@ -26,6 +26,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/cors"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"golang.org/x/sync/semaphore"
 )
 
 const (
@@ -33,6 +34,11 @@ const (
 	ContextKeyReqID              = "req_id"
 	ContextKeyXForwardedFor      = "x_forwarded_for"
 	ContextKeyOpTxProxyAuth      = "op_txproxy_auth"
+	ContextKeyForwardedHeaders   = "forwarded_headers"
+	ContextKeyClientHeaders      = "client_headers"
+	ContextKeyClientTier         = "client_tier"
+	ContextKeyLogSampled         = "log_sampled"
+	DefaultClientTier            = "unknown"
 	DefaultOpTxProxyAuthHeader   = "X-Optimism-Signature"
 	DefaultMaxBatchRPCCallsLimit = 100
 	MaxBatchRPCCallsHardLimit    = 1000
@@ -46,6 +52,7 @@ const (
 	maxRequestBodyLogLen         = 2000
 	defaultMaxUpstreamBatchSize  = 10
 	defaultRateLimitHeader       = "X-Forwarded-For"
+	defaultChainIDCheckTimeout   = 5 * time.Second
 )
 
 var emptyArrayResponse = json.RawMessage("[]")
@@ -76,6 +83,25 @@ type Server struct {
 	cache                  RPCCache
 	srvMu                  sync.Mutex
 	rateLimitHeader        string
+	forwardedHeaders       []string
+	clientTiers            map[string]string
+	logSampleRate          float64
+
+	healthCheckRequireConsensus bool
+	rewriteRequestIDs           bool
+	accessLogger                *AccessLogger
+
+	// wsClientConns counts the open websocket connections of each client, when limited
+	maxWSConnsPerClient int
+	wsClientConnsMu     sync.Mutex
+	wsClientConns       map[string]int
+
+	// state needed to rebuild backends when the config is reloaded
+	reloadMu            sync.Mutex
+	backendsByName      map[string]*Backend
+	backendConfigs      map[string]BackendConfig
+	backendOptions      BackendOptions
+	rpcRequestSemaphore *semaphore.Weighted
 }
 
 type limiterFunc func(method string) bool
@@ -127,8 +153,16 @@ func NewServer(
 	var mainLim FrontendRateLimiter
 	limExemptOrigins := make([]*regexp.Regexp, 0)
 	limExemptUserAgents := make([]*regexp.Regexp, 0)
+	// the soft mode only applies to the main and method limits, not to sender limits
+	rpcLimiterFactory := limiterFactory
+	if rateLimitConfig.Mode == RateLimitModeSoft {
+		rpcLimiterFactory = func(dur time.Duration, max int, prefix string) FrontendRateLimiter {
+			return NewSoftFrontendRateLimiter(limiterFactory(dur, max, prefix), dur, rateLimitConfig.QueueDepth)
+		}
+	}
+
 	if rateLimitConfig.BaseRate > 0 {
-		mainLim = limiterFactory(time.Duration(rateLimitConfig.BaseInterval), rateLimitConfig.BaseRate, "main")
+		mainLim = rpcLimiterFactory(time.Duration(rateLimitConfig.BaseInterval), rateLimitConfig.BaseRate, "main")
 		for _, origin := range rateLimitConfig.ExemptOrigins {
 			pattern, err := regexp.Compile(origin)
 			if err != nil {
@@ -150,7 +184,7 @@ func NewServer(
 	overrideLims := make(map[string]FrontendRateLimiter)
 	globalMethodLims := make(map[string]bool)
 	for method, override := range rateLimitConfig.MethodOverrides {
-		overrideLims[method] = limiterFactory(time.Duration(override.Interval), override.Limit, method)
+		overrideLims[method] = rpcLimiterFactory(time.Duration(override.Interval), override.Limit, method)
 
 		if override.Global {
 			globalMethodLims[method] = true
@@ -166,7 +200,7 @@ func NewServer(
 		rateLimitHeader = rateLimitConfig.IPHeaderOverride
 	}
 
-	return &Server{
+	srv := &Server{
 		BackendGroups:        backendGroups,
 		wsBackendGroup:       wsBackendGroup,
 		wsMethodWhitelist:    wsMethodWhitelist,
@@ -191,7 +225,27 @@ func NewServer(
 		limExemptOrigins:       limExemptOrigins,
 		limExemptUserAgents:    limExemptUserAgents,
 		rateLimitHeader:        rateLimitHeader,
-	}, nil
+	}
+	if c, ok := cache.(*rpcCache); ok {
+		c.setRefresher(srv.refreshCachedRPC)
+	}
+	return srv, nil
+}
+
+// refreshCachedRPC forwards a request to refresh a stale cache entry
+func (s *Server) refreshCachedRPC(ctx context.Context, req *RPCReq) (*RPCRes, error) {
+	bg, ok := s.BackendGroups[s.rpcMethodMappings[req.Method]]
+	if !ok {
+		return nil, fmt.Errorf("no backend group for method %s", req.Method)
+	}
+	res, _, err := bg.ForwardWithGroupFailover(ctx, []*RPCReq{req}, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != 1 {
+		return nil, ErrBackendUnexpectedJSONRPC
+	}
+	return res[0], nil
 }
 
 func (s *Server) RPCListenAndServe(host string, port int) error {
@@ -240,20 +294,53 @@ func (s *Server) Shutdown() {
 	if s.wsServer != nil {
 		_ = s.wsServer.Shutdown(context.Background())
 	}
+	if s.accessLogger != nil {
+		_ = s.accessLogger.Close()
+	}
 	for _, bg := range s.BackendGroups {
 		bg.Shutdown()
 	}
+	s.reloadMu.Lock()
+	for _, back := range s.backendsByName {
+		back.StopHealthCheck()
+	}
+	s.reloadMu.Unlock()
 }
 
+// HandleHealthz reports proxyd as unhealthy if any backend group has no healthy backend, so that
+// load balancers can take it out of rotation
 func (s *Server) HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	for _, bg := range s.BackendGroups {
+		healthy := bg.HasHealthyBackend()
+		if healthy && s.healthCheckRequireConsensus && bg.Consensus != nil {
+			healthy = len(bg.Consensus.GetConsensusGroup()) > 0
+		}
+		if !healthy {
+			log.Warn("reporting unhealthy, backend group has no healthy backends", "group", bg.Name)
+			w.Header().Set("content-type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"status":"unhealthy","reason":"no healthy backends"}`))
+			return
+		}
+	}
 	_, _ = w.Write([]byte("OK"))
 }
 
 func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
+	if s.accessLogger != nil {
+		rw, req, entry := s.accessLogger.begin(w, r)
+		defer s.accessLogger.end(rw, entry)
+		w, r = rw, req
+	}
+
 	ctx := s.populateContext(w, r)
 	if ctx == nil {
 		return
 	}
+	start := time.Now()
+	defer func() {
+		RecordClientTierRequest(ctx, time.Since(start))
+	}()
 	var cancel context.CancelFunc
 	ctx, cancel = context.WithTimeout(ctx, s.timeout)
 	defer cancel()
@@ -295,7 +382,7 @@ func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
 		return !ok
 	}
 
-	log.Debug(
+	GetLogger(ctx).Debug(
 		"received RPC request",
 		"req_id", GetReqID(ctx),
 		"auth", GetAuthCtx(ctx),
@@ -319,7 +406,7 @@ func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
 	RecordRequestPayloadSize(ctx, len(body))
 
 	if s.enableRequestLog {
-		log.Info("Raw RPC request",
+		GetLogger(ctx).Info("Raw RPC request",
 			"body", truncate(string(body), s.maxRequestBodyLogLen),
 			"req_id", GetReqID(ctx),
 			"auth", GetAuthCtx(ctx),
@@ -349,6 +436,7 @@ func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
 		}
 
 		batchRes, batchContainsCached, servedBy, err := s.handleBatchRPC(ctx, reqs, isLimited, true)
+		recordAccessLogServedBy(ctx, servedBy)
 		if err == context.DeadlineExceeded {
 			writeRPCError(ctx, w, nil, ErrGatewayTimeout)
 			return
@@ -358,6 +446,11 @@ func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
 			writeRPCError(ctx, w, nil, ErrInvalidRequest(err.Error()))
 			return
 		}
+		if errors.Is(err, ErrTooManyBatchRequests) {
+			RecordRPCError(ctx, BackendProxyd, MethodUnknown, ErrTooManyBatchRequests)
+			writeRPCError(ctx, w, nil, ErrTooManyBatchRequests)
+			return
+		}
 		if err != nil {
 			writeRPCError(ctx, w, nil, ErrInternal)
 			return
@@ -372,6 +465,7 @@ func (s *Server) HandleRPC(w http.ResponseWriter, r *http.Request) {
 
 	rawBody := json.RawMessage(body)
 	backendRes, cached, servedBy, err := s.handleBatchRPC(ctx, []json.RawMessage{rawBody}, isLimited, false)
+	recordAccessLogServedBy(ctx, servedBy)
 	if err != nil {
 		if errors.Is(err, ErrConsensusGetReceiptsCantBeBatched) ||
 			errors.Is(err, ErrConsensusGetReceiptsInvalidTarget) {
@@ -407,7 +501,7 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 	for i := range reqs {
 		parsedReq, err := ParseRPCReq(reqs[i])
 		if err != nil {
-			log.Info("error parsing RPC call", "source", "rpc", "err", err)
+			GetLogger(ctx).Info("error parsing RPC call", "source", "rpc", "err", err)
 			responses[i] = NewRPCErrorRes(nil, err)
 			continue
 		}
@@ -429,6 +523,7 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 		}
 
 		if parsedReq.Method == "eth_accounts" {
+			recordAccessLogMethod(ctx, parsedReq.Method)
 			RecordRPCForward(ctx, BackendProxyd, "eth_accounts", RPCRequestSourceHTTP)
 			responses[i] = NewRPCRes(parsedReq.ID, emptyArrayResponse)
 			continue
@@ -436,22 +531,26 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 
 		group := s.rpcMethodMappings[parsedReq.Method]
 		if group == "" {
+			recordAccessLogMethod(ctx, MethodUnknown)
 			// use unknown below to prevent DOS vector that fills up memory
 			// with arbitrary method names.
-			log.Info(
+			GetLogger(ctx).Info(
 				"blocked request for non-whitelisted method",
 				"source", "rpc",
 				"req_id", GetReqID(ctx),
 				"method", parsedReq.Method,
 			)
 			RecordRPCError(ctx, BackendProxyd, MethodUnknown, ErrMethodNotWhitelisted)
+			RecordRejectedMethod(parsedReq.Method)
 			responses[i] = NewRPCErrorRes(parsedReq.ID, ErrMethodNotWhitelisted)
 			continue
 		}
 
+		recordAccessLogMethod(ctx, parsedReq.Method)
+
 		// Take base rate limit first
 		if isLimited("") {
-			log.Debug(
+			GetLogger(ctx).Debug(
 				"rate limited individual RPC in a batch request",
 				"source", "rpc",
 				"req_id", parsedReq.ID,
@@ -464,7 +563,7 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 
 		// Take rate limit for specific methods.
 		if _, ok := s.overrideLims[parsedReq.Method]; ok && isLimited(parsedReq.Method) {
-			log.Debug(
+			GetLogger(ctx).Debug(
 				"rate limited specific RPC",
 				"source", "rpc",
 				"req_id", GetReqID(ctx),
@@ -486,14 +585,35 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 			}
 		}
 
-		id := string(parsedReq.ID)
-		// If this is a duplicate Request ID, move the Request to a new batchGroup
-		ids[id]++
-		batchGroupID := ids[id]
+		batchGroupID := 1
+		if !s.rewriteRequestIDs {
+			id := string(parsedReq.ID)
+			// If this is a duplicate Request ID, move the Request to a new batchGroup
+			ids[id]++
+			batchGroupID = ids[id]
+		}
 		batchGroup := batchGroup{groupID: batchGroupID, backendGroup: group}
 		batches[batchGroup] = append(batches[batchGroup], batchElem{parsedReq, i})
 	}
 
+	// backend groups may limit the size of the batches routed to them, which is enforced
+	// before anything is forwarded
+	groupSizes := make(map[string]int)
+	for group, batch := range batches {
+		groupSizes[group.backendGroup] += len(batch)
+	}
+	for name, size := range groupSizes {
+		if s.BackendGroups[name].rejectsBatch(size) {
+			GetLogger(ctx).Info(
+				"rejected batch exceeding backend group max batch size",
+				"req_id", GetReqID(ctx),
+				"backend_group", name,
+				"batch_size", size,
+			)
+			return nil, false, "", ErrTooManyBatchRequests
+		}
+	}
+
 	servedBy := make(map[string]bool, 0)
 	var cached bool
 	for group, batch := range batches {
@@ -513,7 +633,7 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 		numBatches := int(math.Ceil(float64(len(cacheMisses)) / float64(s.maxUpstreamBatchSize)))
 		for i := 0; i < numBatches; i++ {
 			if ctx.Err() == context.DeadlineExceeded {
-				log.Info("short-circuiting batch RPC",
+				GetLogger(ctx).Info("short-circuiting batch RPC",
 					"req_id", GetReqID(ctx),
 					"auth", GetAuthCtx(ctx),
 					"batch_index", i,
@@ -525,7 +645,11 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 			start := i * s.maxUpstreamBatchSize
 			end := int(math.Min(float64(start+s.maxUpstreamBatchSize), float64(len(cacheMisses))))
 			elems := cacheMisses[start:end]
-			res, sb, err := s.BackendGroups[group.backendGroup].Forward(ctx, createBatchRequest(elems), isBatch)
+			batchReq := createBatchRequest(elems)
+			if s.rewriteRequestIDs {
+				batchReq = createRewrittenBatchRequest(elems)
+			}
+			res, sb, err := s.BackendGroups[group.backendGroup].ForwardWithGroupFailover(ctx, batchReq, isBatch)
 			servedBy[sb] = true
 			if err != nil {
 				if errors.Is(err, ErrConsensusGetReceiptsCantBeBatched) ||
@@ -546,6 +670,8 @@ func (s *Server) handleBatchRPC(ctx context.Context, reqs []json.RawMessage, isL
 			}
 
 			for i := range elems {
+				// responses are in request order, so this also restores rewritten IDs
+				res[i].ID = elems[i].Req.ID
 				responses[elems[i].Index] = res[i]
 
 				// TODO(inphi): batch put these
@@ -579,11 +705,21 @@ func (s *Server) HandleWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Info("received WS connection", "req_id", GetReqID(ctx))
+	GetLogger(ctx).Info("received WS connection", "req_id", GetReqID(ctx))
+
+	client := wsClientIdentity(ctx)
+	if !s.acquireWSClientConn(client) {
+		GetLogger(ctx).Info("rejected WS connection over the per client limit",
+			"auth", GetAuthCtx(ctx), "req_id", GetReqID(ctx), "limit", s.maxWSConnsPerClient)
+		RecordWSConnRejected(ctx)
+		writeRPCError(ctx, w, nil, ErrTooManyWSConns)
+		return
+	}
 
 	clientConn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Error("error upgrading client conn", "auth", GetAuthCtx(ctx), "req_id", GetReqID(ctx), "err", err)
+		s.releaseWSClientConn(client)
 		return
 	}
 	clientConn.SetReadLimit(s.maxBodySize)
@@ -595,6 +731,7 @@ func (s *Server) HandleWS(w http.ResponseWriter, r *http.Request) {
 		}
 		log.Error("error dialing ws backend", "auth", GetAuthCtx(ctx), "req_id", GetReqID(ctx), "err", err)
 		clientConn.Close()
+		s.releaseWSClientConn(client)
 		return
 	}
 
@@ -605,9 +742,51 @@ func (s *Server) HandleWS(w http.ResponseWriter, r *http.Request) {
 			log.Error("error proxying websocket", "auth", GetAuthCtx(ctx), "req_id", GetReqID(ctx), "err", err)
 		}
 		activeClientWsConnsGauge.WithLabelValues(GetAuthCtx(ctx)).Dec()
+		s.releaseWSClientConn(client)
 	}()
 
-	log.Info("accepted WS connection", "auth", GetAuthCtx(ctx), "req_id", GetReqID(ctx))
+	GetLogger(ctx).Info("accepted WS connection", "auth", GetAuthCtx(ctx), "req_id", GetReqID(ctx))
+}
+
+// wsClientIdentity identifies the client of a websocket connection by its authentication, or
+// else its IP
+func wsClientIdentity(ctx context.Context) string {
+	if auth, ok := ctx.Value(ContextKeyAuth).(string); ok {
+		return "auth:" + auth
+	}
+	return "ip:" + GetXForwardedFor(ctx)
+}
+
+// acquireWSClientConn counts a new websocket connection of the client, and returns false
+// without counting it if the client is at its limit
+func (s *Server) acquireWSClientConn(client string) bool {
+	if s.maxWSConnsPerClient <= 0 {
+		return true
+	}
+	s.wsClientConnsMu.Lock()
+	defer s.wsClientConnsMu.Unlock()
+	if s.wsClientConns[client] >= s.maxWSConnsPerClient {
+		return false
+	}
+	if s.wsClientConns == nil {
+		s.wsClientConns = make(map[string]int)
+	}
+	s.wsClientConns[client]++
+	return true
+}
+
+// releaseWSClientConn uncounts a closed websocket connection of the client
+func (s *Server) releaseWSClientConn(client string) {
+	if s.maxWSConnsPerClient <= 0 {
+		return
+	}
+	s.wsClientConnsMu.Lock()
+	defer s.wsClientConnsMu.Unlock()
+	if s.wsClientConns[client] <= 1 {
+		delete(s.wsClientConns, client)
+		return
+	}
+	s.wsClientConns[client]--
 }
 
 func (s *Server) populateContext(w http.ResponseWriter, r *http.Request) context.Context {
@@ -622,12 +801,27 @@ func (s *Server) populateContext(w http.ResponseWriter, r *http.Request) context
 	}
 
 	ctx := context.WithValue(r.Context(), ContextKeyXForwardedFor, xff) // nolint:staticcheck
+	if entry := getAccessLogEntry(ctx); entry != nil {
+		entry.remoteIP = xff
+	}
 
 	opTxProxyAuth := r.Header.Get(DefaultOpTxProxyAuthHeader)
 	if opTxProxyAuth != "" {
 		ctx = context.WithValue(ctx, ContextKeyOpTxProxyAuth, opTxProxyAuth) // nolint:staticcheck
 	}
 
+	if len(s.forwardedHeaders) > 0 {
+		forwarded := make(http.Header)
+		for _, name := range s.forwardedHeaders {
+			if values := r.Header.Values(name); len(values) > 0 {
+				forwarded[name] = values
+			}
+		}
+		ctx = context.WithValue(ctx, ContextKeyForwardedHeaders, forwarded) // nolint:staticcheck
+	}
+
+	ctx = context.WithValue(ctx, ContextKeyClientHeaders, r.Header) // nolint:staticcheck
+
 	if len(s.authenticatedPaths) > 0 {
 		if authorization == "" || s.authenticatedPaths[authorization] == "" {
 			log.Info("blocked unauthorized request", "authorization", authorization)
@@ -639,11 +833,25 @@ func (s *Server) populateContext(w http.ResponseWriter, r *http.Request) context
 		ctx = context.WithValue(ctx, ContextKeyAuth, s.authenticatedPaths[authorization]) // nolint:staticcheck
 	}
 
-	return context.WithValue(
+	if tier, ok := s.clientTiers[GetAuthCtx(ctx)]; ok {
+		ctx = context.WithValue(ctx, ContextKeyClientTier, tier) // nolint:staticcheck
+	}
+
+	// the sampling decision is made once so that a request logs consistently
+	if s.logSampleRate > 0 {
+		ctx = context.WithValue(ctx, ContextKeyLogSampled, sampleLogs(s.logSampleRate)) // nolint:staticcheck
+	}
+
+	ctx = context.WithValue(
 		ctx,
 		ContextKeyReqID, // nolint:staticcheck
 		randStr(10),
 	)
+	if entry := getAccessLogEntry(ctx); entry != nil {
+		entry.auth = GetAuthCtx(ctx)
+		entry.reqID = GetReqID(ctx)
+	}
+	return ctx
 }
 
 func randStr(l int) string {
@@ -654,6 +862,17 @@ func randStr(l int) string {
 	return hex.EncodeToString(b)
 }
 
+// newUUID returns a random (version 4) UUID
+func newUUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
 func (s *Server) isUnlimitedOrigin(origin string) bool {
 	for _, pat := range s.limExemptOrigins {
 		if pat.MatchString(origin) {
@@ -777,6 +996,9 @@ func writeRPCRes(ctx context.Context, w http.ResponseWriter, res *RPCRes) {
 	}
 	httpResponseCodesTotal.WithLabelValues(strconv.Itoa(statusCode)).Inc()
 	RecordResponsePayloadSize(ctx, ww.Len)
+	if res.IsError() {
+		RecordClientTierErrors(ctx, 1)
+	}
 }
 
 func writeBatchRPCRes(ctx context.Context, w http.ResponseWriter, res []*RPCRes) {
@@ -790,6 +1012,14 @@ func writeBatchRPCRes(ctx context.Context, w http.ResponseWriter, res []*RPCRes)
 		return
 	}
 	RecordResponsePayloadSize(ctx, ww.Len)
+
+	errCount := 0
+	for _, r := range res {
+		if r.IsError() {
+			errCount++
+		}
+	}
+	RecordClientTierErrors(ctx, errCount)
 }
 
 func instrumentedHdlr(h http.Handler) http.HandlerFunc {
@@ -817,6 +1047,32 @@ func GetOpTxProxyAuthHeader(ctx context.Context) string {
 	return auth
 }
 
+func GetForwardedHeaders(ctx context.Context) http.Header {
+	headers, ok := ctx.Value(ContextKeyForwardedHeaders).(http.Header)
+	if !ok {
+		return nil
+	}
+	return headers
+}
+
+// GetClientHeader returns the value of a header as sent by the client, if any
+func GetClientHeader(ctx context.Context, name string) string {
+	headers, ok := ctx.Value(ContextKeyClientHeaders).(http.Header)
+	if !ok {
+		return ""
+	}
+	return headers.Get(name)
+}
+
+// GetClientTier returns the tier of the client's auth alias, or DefaultClientTier if it isn't mapped
+func GetClientTier(ctx context.Context) string {
+	tier, ok := ctx.Value(ContextKeyClientTier).(string)
+	if !ok {
+		return DefaultClientTier
+	}
+	return tier
+}
+
 func GetReqID(ctx context.Context) string {
 	reqId, ok := ctx.Value(ContextKeyReqID).(string)
 	if !ok {
@@ -878,3 +1134,15 @@ func createBatchRequest(elems []batchElem) []*RPCReq {
 	}
 	return batch
 }
+
+// createRewrittenBatchRequest is like createBatchRequest, but replaces each request ID with
+// its position in the batch, so requests with duplicate or null IDs can be forwarded together
+func createRewrittenBatchRequest(elems []batchElem) []*RPCReq {
+	batch := make([]*RPCReq, len(elems))
+	for i := range elems {
+		req := *elems[i].Req
+		req.ID = json.RawMessage(strconv.Itoa(i))
+		batch[i] = &req
+	}
+	return batch
+}
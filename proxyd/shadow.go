@@ -0,0 +1,131 @@
+package proxyd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	ShadowOutcomeMatch    = "match"
+	ShadowOutcomeDiverged = "diverged"
+	ShadowOutcomeError    = "error"
+
+	defaultShadowTimeout = 10 * time.Second
+)
+
+// shadowBackend mirrors a sample of a group's traffic to a backend whose responses are
+// discarded, and compares them against the responses served to the client
+type shadowBackend struct {
+	backend       *Backend
+	sampleRate    float64
+	logDivergence bool
+}
+
+// WithShadowBackend mirrors sampleRate (0 to 1) of the group's requests to the given backend
+func WithShadowBackend(be *Backend, sampleRate float64, logDivergence bool) BackendGroupOpt {
+	return func(bg *BackendGroup) {
+		bg.shadow = &shadowBackend{
+			backend:       be,
+			sampleRate:    sampleRate,
+			logDivergence: logDivergence,
+		}
+	}
+}
+
+// maybeMirrorToShadow asynchronously sends a sample of the served requests to the shadow backend.
+// It never blocks the client, and the shadow responses are only used for comparison.
+func (bg *BackendGroup) maybeMirrorToShadow(ctx context.Context, rpcReqs []*RPCReq, isBatch bool, served []*RPCRes) {
+	if bg.shadow == nil || rand.Float64() >= bg.shadow.sampleRate {
+		return
+	}
+
+	reqs := copyRPCReqs(rpcReqs)
+	servedCopy := make([]*RPCRes, len(served))
+	for i, res := range served {
+		c := *res
+		servedCopy[i] = &c
+	}
+
+	// keep the request context values (e.g. req_id) but not its cancellation, since
+	// the client request completes before the shadow request does
+	shadowCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultShadowTimeout)
+	go func() {
+		defer cancel()
+		bg.shadow.forward(shadowCtx, bg.Name, reqs, isBatch, servedCopy)
+	}()
+}
+
+func (s *shadowBackend) forward(ctx context.Context, groupName string, reqs []*RPCReq, isBatch bool, served []*RPCRes) {
+	res, err := s.backend.Forward(ctx, reqs, isBatch)
+	if err != nil {
+		RecordShadowRequest(groupName, s.backend.Name, ShadowOutcomeError)
+		log.Debug("shadow backend request failed",
+			"group", groupName,
+			"backend", s.backend.Name,
+			"req_id", GetReqID(ctx),
+			"err", err,
+		)
+		return
+	}
+
+	diverged := shadowDivergences(reqs, served, res)
+	if len(diverged) == 0 {
+		RecordShadowRequest(groupName, s.backend.Name, ShadowOutcomeMatch)
+		return
+	}
+
+	RecordShadowRequest(groupName, s.backend.Name, ShadowOutcomeDiverged)
+	if s.logDivergence {
+		log.Info("shadow backend response diverged",
+			"group", groupName,
+			"backend", s.backend.Name,
+			"req_id", GetReqID(ctx),
+			"methods", diverged,
+		)
+	}
+}
+
+// shadowDivergences returns the methods of the requests whose shadow response differs from the
+// served response. Responses are paired by request ID.
+func shadowDivergences(reqs []*RPCReq, served []*RPCRes, shadow []*RPCRes) []string {
+	servedByID := make(map[string]*RPCRes, len(served))
+	for _, res := range served {
+		servedByID[string(res.ID)] = res
+	}
+	shadowByID := make(map[string]*RPCRes, len(shadow))
+	for _, res := range shadow {
+		shadowByID[string(res.ID)] = res
+	}
+
+	var diverged []string
+	for _, req := range reqs {
+		id := string(req.ID)
+		if !equalRPCRes(servedByID[id], shadowByID[id]) {
+			diverged = append(diverged, req.Method)
+		}
+	}
+	return diverged
+}
+
+func equalRPCRes(a, b *RPCRes) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.IsError() || b.IsError() {
+		return a.IsError() && b.IsError() && a.Error.Code == b.Error.Code
+	}
+	aResult, err := json.Marshal(a.Result)
+	if err != nil {
+		return false
+	}
+	bResult, err := json.Marshal(b.Result)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aResult, bResult)
+}
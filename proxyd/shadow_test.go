@@ -0,0 +1,67 @@
+package proxyd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowDivergences(t *testing.T) {
+	reqs := []*RPCReq{
+		{Method: "eth_chainId", ID: json.RawMessage("1")},
+		{Method: "eth_blockNumber", ID: json.RawMessage("2")},
+		{Method: "eth_call", ID: json.RawMessage("3")},
+	}
+	served := []*RPCRes{
+		{ID: json.RawMessage("1"), Result: "0xa"},
+		{ID: json.RawMessage("2"), Result: "0x100"},
+		{ID: json.RawMessage("3"), Error: &RPCErr{Code: 3, Message: "execution reverted"}},
+	}
+
+	tests := []struct {
+		name     string
+		shadow   []*RPCRes
+		expected []string
+	}{
+		{
+			name: "identical responses in a different order",
+			shadow: []*RPCRes{
+				{ID: json.RawMessage("3"), Error: &RPCErr{Code: 3, Message: "execution reverted: reason"}},
+				{ID: json.RawMessage("2"), Result: "0x100"},
+				{ID: json.RawMessage("1"), Result: "0xa"},
+			},
+		},
+		{
+			name: "different result",
+			shadow: []*RPCRes{
+				{ID: json.RawMessage("1"), Result: "0xa"},
+				{ID: json.RawMessage("2"), Result: "0xff"},
+				{ID: json.RawMessage("3"), Error: &RPCErr{Code: 3, Message: "execution reverted"}},
+			},
+			expected: []string{"eth_blockNumber"},
+		},
+		{
+			name: "error instead of result and different error code",
+			shadow: []*RPCRes{
+				{ID: json.RawMessage("1"), Error: &RPCErr{Code: -32000, Message: "header not found"}},
+				{ID: json.RawMessage("2"), Result: "0x100"},
+				{ID: json.RawMessage("3"), Error: &RPCErr{Code: -32000, Message: "execution reverted"}},
+			},
+			expected: []string{"eth_chainId", "eth_call"},
+		},
+		{
+			name: "missing response",
+			shadow: []*RPCRes{
+				{ID: json.RawMessage("1"), Result: "0xa"},
+				{ID: json.RawMessage("3"), Error: &RPCErr{Code: 3, Message: "execution reverted"}},
+			},
+			expected: []string{"eth_blockNumber"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, shadowDivergences(reqs, served, tt.shadow))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package proxyd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+)
+
+func TestSlowRequestLog(t *testing.T) {
+	var delay atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Duration(delay.Load()))
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","result":"0x1","id":1}`))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	defer log.SetDefault(log.Root())
+	log.SetDefault(log.NewLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	b := NewBackend("slow", srv.URL, "", semaphore.NewWeighted(1),
+		WithSlowRequestThreshold(100*time.Millisecond),
+		WithStrippedTrailingXFF(),
+	)
+	reqs := []*RPCReq{{JSONRPC: JSONRPCVersion, Method: "eth_call", ID: json.RawMessage("1")}}
+	slowRequests := func() float64 {
+		return testutil.ToFloat64(slowBackendRequestsTotal.WithLabelValues("slow", "eth_call"))
+	}
+
+	_, err := b.Forward(context.Background(), reqs, false)
+	require.NoError(t, err)
+	require.Equal(t, 0.0, slowRequests())
+	require.NotContains(t, buf.String(), "slow backend request")
+
+	delay.Store(int64(150 * time.Millisecond))
+	_, err = b.Forward(context.Background(), reqs, false)
+	require.NoError(t, err)
+	require.Equal(t, 1.0, slowRequests())
+	require.Contains(t, buf.String(), "slow backend request")
+	require.Contains(t, buf.String(), `"method":"eth_call"`)
+}
@@ -1,12 +1,20 @@
 package proxyd
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
 )
 
+var ErrTLSCertificatePinMismatch = errors.New("tls certificate does not match any pinned fingerprint")
+
 func CreateTLSClient(ca string) (*tls.Config, error) {
 	pem, err := os.ReadFile(ca)
 	if err != nil {
@@ -31,3 +39,34 @@ func ParseKeyPair(crt, key string) (tls.Certificate, error) {
 	}
 	return cert, nil
 }
+
+// NormalizeCertificateFingerprint lowercases a SHA-256 certificate fingerprint and strips the
+// colon separators some tools print, returning an error if it isn't 32 hex encoded bytes
+func NormalizeCertificateFingerprint(fingerprint string) (string, error) {
+	normalized := strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+	b, err := hex.DecodeString(normalized)
+	if err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("invalid SHA-256 certificate fingerprint: %s", fingerprint)
+	}
+	return normalized, nil
+}
+
+// certificatePinVerifier returns a tls.Config VerifyPeerCertificate callback that accepts the
+// connection if any certificate of the chain matches one of the pinned fingerprints
+func certificatePinVerifier(backendName string, pins []string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		observed := make([]string, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			sum := sha256.Sum256(raw)
+			fingerprint := hex.EncodeToString(sum[:])
+			for _, pin := range pins {
+				if fingerprint == pin {
+					log.Debug("TLS certificate pin verified for backend "+backendName, "fingerprint", fingerprint)
+					return nil
+				}
+			}
+			observed = append(observed, fingerprint)
+		}
+		return fmt.Errorf("%w: backend %s presented %s", ErrTLSCertificatePinMismatch, backendName, strings.Join(observed, ", "))
+	}
+}
@@ -0,0 +1,129 @@
+package proxyd
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSCertificatePin(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name   string
+		pins   []string
+		expErr bool
+	}{
+		{"pin matches", []string{fingerprint}, false},
+		{"one of several pins matches", []string{"00" + fingerprint[2:], fingerprint}, false},
+		{"pin mismatch", []string{"00" + fingerprint[2:]}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBackend("pinned", srv.URL, "", nil,
+				WithTLSConfig(&tls.Config{RootCAs: roots}),
+				WithTLSCertificatePin(tt.pins),
+			)
+
+			res, err := b.client.Get(srv.URL)
+			if tt.expErr {
+				require.ErrorContains(t, err, ErrTLSCertificatePinMismatch.Error())
+				require.ErrorContains(t, err, fingerprint)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			require.Equal(t, http.StatusOK, res.StatusCode)
+		})
+	}
+}
+
+func TestTLSCertificatePinBeforeTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	b := NewBackend("pinned", srv.URL, "", nil,
+		WithTLSCertificatePin([]string{"00" + hex.EncodeToString(make([]byte, 31))}),
+		WithTLSConfig(&tls.Config{RootCAs: roots}),
+	)
+
+	_, err := b.client.Get(srv.URL)
+	require.ErrorContains(t, err, ErrTLSCertificatePinMismatch.Error())
+}
+
+func TestNormalizeCertificateFingerprint(t *testing.T) {
+	sum := sha256.Sum256([]byte("cert"))
+	fingerprint := hex.EncodeToString(sum[:])
+
+	normalized, err := NormalizeCertificateFingerprint(fingerprint)
+	require.NoError(t, err)
+	require.Equal(t, fingerprint, normalized)
+
+	colons := ""
+	for i := 0; i < len(fingerprint); i += 2 {
+		if i > 0 {
+			colons += ":"
+		}
+		colons += fingerprint[i : i+2]
+	}
+	normalized, err = NormalizeCertificateFingerprint(colons)
+	require.NoError(t, err)
+	require.Equal(t, fingerprint, normalized)
+
+	_, err = NormalizeCertificateFingerprint("abcd")
+	require.Error(t, err)
+	_, err = NormalizeCertificateFingerprint("zz" + fingerprint[2:])
+	require.Error(t, err)
+}
+
+func TestTLSCertificatePinWebsocket(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+	}))
+	defer srv.Close()
+	wsURL := "wss" + strings.TrimPrefix(srv.URL, "https")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	b := NewBackend("pinned", srv.URL, wsURL, nil,
+		WithTLSConfig(&tls.Config{RootCAs: roots}),
+		WithTLSCertificatePin([]string{fingerprint}),
+	)
+	conn, _, err := b.dialer.Dial(wsURL, nil) // nolint:bodyclose
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	b = NewBackend("pinned", srv.URL, wsURL, nil,
+		WithTLSConfig(&tls.Config{RootCAs: roots}),
+		WithTLSCertificatePin([]string{"00" + fingerprint[2:]}),
+	)
+	_, _, err = b.dialer.Dial(wsURL, nil) // nolint:bodyclose
+	require.ErrorContains(t, err, ErrTLSCertificatePinMismatch.Error())
+}
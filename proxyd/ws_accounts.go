@@ -0,0 +1,44 @@
+package proxyd
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// defaultAccountsInterceptList are the account methods answered by proxyd itself on WS
+// connections, since proxyd never holds any accounts
+var defaultAccountsInterceptList = []string{"eth_accounts", "eth_requestAccounts", "eth_coinbase", "wallet_accounts"}
+
+var defaultAccountsInterceptResponses = map[string]json.RawMessage{
+	"eth_accounts":        emptyArrayResponse,
+	"eth_requestAccounts": emptyArrayResponse,
+	"eth_coinbase":        json.RawMessage("null"),
+	"wallet_accounts":     emptyArrayResponse,
+}
+
+// NewAccountsIntercept builds the method to response mapping of intercepted account methods.
+// Responses are JSON literals; methods without a configured or default response return an empty array.
+func NewAccountsIntercept(methods []string, responses map[string]string) (map[string]json.RawMessage, error) {
+	if len(methods) == 0 {
+		methods = defaultAccountsInterceptList
+	}
+
+	intercept := make(map[string]json.RawMessage, len(methods))
+	for _, method := range methods {
+		res, ok := defaultAccountsInterceptResponses[method]
+		if !ok {
+			res = emptyArrayResponse
+		}
+		intercept[method] = res
+	}
+	for method, res := range responses {
+		if _, ok := intercept[method]; !ok {
+			return nil, fmt.Errorf("response configured for %s, which is not in the accounts intercept list", method)
+		}
+		if !json.Valid([]byte(res)) {
+			return nil, fmt.Errorf("invalid JSON response configured for %s: %s", method, res)
+		}
+		intercept[method] = json.RawMessage(res)
+	}
+	return intercept, nil
+}
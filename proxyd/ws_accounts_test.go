@@ -0,0 +1,27 @@
+package proxyd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAccountsIntercept(t *testing.T) {
+	intercept, err := NewAccountsIntercept(nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, defaultAccountsInterceptResponses, intercept)
+
+	intercept, err = NewAccountsIntercept([]string{"eth_accounts", "personal_listAccounts"}, map[string]string{"eth_accounts": "null"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]json.RawMessage{
+		"eth_accounts":          json.RawMessage("null"),
+		"personal_listAccounts": emptyArrayResponse,
+	}, intercept)
+
+	_, err = NewAccountsIntercept([]string{"eth_accounts"}, map[string]string{"eth_coinbase": "null"})
+	require.Error(t, err)
+
+	_, err = NewAccountsIntercept(nil, map[string]string{"eth_accounts": "[not json"})
+	require.Error(t, err)
+}
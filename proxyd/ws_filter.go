@@ -0,0 +1,194 @@
+package proxyd
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// LogFilter is the address/topic criteria of an eth_subscribe("logs", ...) subscription
+type LogFilter struct {
+	Addresses []common.Address
+	// Topics holds the accepted values per topic position. An empty position matches any topic.
+	Topics [][]common.Hash
+}
+
+type logFilterJSON struct {
+	Address json.RawMessage   `json:"address"`
+	Topics  []json.RawMessage `json:"topics"`
+}
+
+type logJSON struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+}
+
+// ParseLogFilter parses the filter object of a logs subscription.
+// `address` may be a single address or an array, and each entry of `topics`
+// may be null, a single topic, or an array of alternatives.
+func ParseLogFilter(raw json.RawMessage) (*LogFilter, error) {
+	var fj logFilterJSON
+	if err := json.Unmarshal(raw, &fj); err != nil {
+		return nil, err
+	}
+
+	filter := &LogFilter{}
+	addrs, err := parseOneOrMany[common.Address](fj.Address)
+	if err != nil {
+		return nil, err
+	}
+	filter.Addresses = addrs
+
+	for _, rawTopic := range fj.Topics {
+		topics, err := parseOneOrMany[common.Hash](rawTopic)
+		if err != nil {
+			return nil, err
+		}
+		filter.Topics = append(filter.Topics, topics)
+	}
+	return filter, nil
+}
+
+func parseOneOrMany[T any](raw json.RawMessage) ([]T, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if raw[0] == '[' {
+		var many []T
+		if err := json.Unmarshal(raw, &many); err != nil {
+			return nil, err
+		}
+		return many, nil
+	}
+	var one T
+	if err := json.Unmarshal(raw, &one); err != nil {
+		return nil, err
+	}
+	return []T{one}, nil
+}
+
+// Matches reports whether the log satisfies the filter, following the same semantics as eth_getLogs
+func (f *LogFilter) Matches(address common.Address, topics []common.Hash) bool {
+	if len(f.Addresses) > 0 && !slices.Contains(f.Addresses, address) {
+		return false
+	}
+	if len(f.Topics) > len(topics) {
+		return false
+	}
+	for i, sub := range f.Topics {
+		if len(sub) > 0 && !slices.Contains(sub, topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// wsSubscriptionFilters tracks the log filters of the subscriptions on a single WS connection,
+// so that log notifications the client did not ask for can be dropped before being forwarded.
+type wsSubscriptionFilters struct {
+	mu sync.Mutex
+	// pending maps the request id of an eth_subscribe call to its filter until the backend replies
+	pending map[string]*LogFilter
+	// active maps subscription ids to their filter
+	active map[string]*LogFilter
+}
+
+func newWSSubscriptionFilters() *wsSubscriptionFilters {
+	return &wsSubscriptionFilters{
+		pending: make(map[string]*LogFilter),
+		active:  make(map[string]*LogFilter),
+	}
+}
+
+// TrackRequest inspects a client request for logs subscriptions and unsubscriptions
+func (f *wsSubscriptionFilters) TrackRequest(req *RPCReq) error {
+	switch req.Method {
+	case "eth_subscribe":
+		var params []json.RawMessage
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return err
+		}
+		if len(params) < 2 {
+			return nil
+		}
+		var kind string
+		if err := json.Unmarshal(params[0], &kind); err != nil {
+			return err
+		}
+		// only logs subscriptions carry filter criteria, e.g. newHeads and newPendingTransactions are never filtered
+		if kind != "logs" {
+			return nil
+		}
+		filter, err := ParseLogFilter(params[1])
+		if err != nil {
+			return err
+		}
+		f.mu.Lock()
+		f.pending[string(req.ID)] = filter
+		f.mu.Unlock()
+	case "eth_unsubscribe":
+		var params []string
+		if err := json.Unmarshal(req.Params, &params); err != nil || len(params) == 0 {
+			return nil
+		}
+		f.mu.Lock()
+		delete(f.active, params[0])
+		f.mu.Unlock()
+	}
+	return nil
+}
+
+// TrackResponse activates the filter of a pending subscription once the backend assigns it an id
+func (f *wsSubscriptionFilters) TrackResponse(res *RPCRes) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	filter, ok := f.pending[string(res.ID)]
+	if !ok {
+		return
+	}
+	delete(f.pending, string(res.ID))
+	if subID, ok := res.Result.(string); ok && !res.IsError() {
+		f.active[subID] = filter
+	}
+}
+
+type wsNotificationJSON struct {
+	Method string `json:"method"`
+	Params struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+var errNotLogNotification = errors.New("not a log notification")
+
+// ShouldForward reports whether a backend message should be forwarded to the client.
+// Only log notifications for filtered subscriptions that don't match are dropped.
+func (f *wsSubscriptionFilters) ShouldForward(msg []byte) bool {
+	filter, lg, err := f.lookupNotification(msg)
+	if err != nil {
+		return true
+	}
+	return filter.Matches(lg.Address, lg.Topics)
+}
+
+func (f *wsSubscriptionFilters) lookupNotification(msg []byte) (*LogFilter, *logJSON, error) {
+	var n wsNotificationJSON
+	if err := json.Unmarshal(msg, &n); err != nil || n.Method != "eth_subscription" {
+		return nil, nil, errNotLogNotification
+	}
+	f.mu.Lock()
+	filter, ok := f.active[n.Params.Subscription]
+	f.mu.Unlock()
+	if !ok {
+		return nil, nil, errNotLogNotification
+	}
+	var lg logJSON
+	if err := json.Unmarshal(n.Params.Result, &lg); err != nil {
+		return nil, nil, errNotLogNotification
+	}
+	return filter, &lg, nil
+}
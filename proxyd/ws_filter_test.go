@@ -0,0 +1,83 @@
+package proxyd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	filterAddrA  = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+	filterAddrB  = common.HexToAddress("0x000000000000000000000000000000000000bbbb")
+	filterTopic1 = common.HexToHash("0x01")
+	filterTopic2 = common.HexToHash("0x02")
+	filterTopic3 = common.HexToHash("0x03")
+)
+
+func TestParseLogFilter(t *testing.T) {
+	f, err := ParseLogFilter(json.RawMessage(`{"address":"0x000000000000000000000000000000000000aaaa","topics":[null,"0x0000000000000000000000000000000000000000000000000000000000000001",["0x0000000000000000000000000000000000000000000000000000000000000002","0x0000000000000000000000000000000000000000000000000000000000000003"]]}`))
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{filterAddrA}, f.Addresses)
+	require.Equal(t, [][]common.Hash{nil, {filterTopic1}, {filterTopic2, filterTopic3}}, f.Topics)
+
+	f, err = ParseLogFilter(json.RawMessage(`{"address":["0x000000000000000000000000000000000000aaaa","0x000000000000000000000000000000000000bbbb"]}`))
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{filterAddrA, filterAddrB}, f.Addresses)
+	require.Empty(t, f.Topics)
+
+	_, err = ParseLogFilter(json.RawMessage(`{"address":"not an address"}`))
+	require.Error(t, err)
+}
+
+func TestLogFilterMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  LogFilter
+		address common.Address
+		topics  []common.Hash
+		want    bool
+	}{
+		{"empty filter matches everything", LogFilter{}, filterAddrA, []common.Hash{filterTopic1}, true},
+		{"address match", LogFilter{Addresses: []common.Address{filterAddrA, filterAddrB}}, filterAddrB, nil, true},
+		{"address mismatch", LogFilter{Addresses: []common.Address{filterAddrA}}, filterAddrB, nil, false},
+		{"topic match", LogFilter{Topics: [][]common.Hash{{filterTopic1}}}, filterAddrA, []common.Hash{filterTopic1, filterTopic2}, true},
+		{"topic mismatch", LogFilter{Topics: [][]common.Hash{{filterTopic2}}}, filterAddrA, []common.Hash{filterTopic1}, false},
+		{"wildcard topic position", LogFilter{Topics: [][]common.Hash{nil, {filterTopic2}}}, filterAddrA, []common.Hash{filterTopic3, filterTopic2}, true},
+		{"topic alternatives", LogFilter{Topics: [][]common.Hash{{filterTopic2, filterTopic3}}}, filterAddrA, []common.Hash{filterTopic3}, true},
+		{"more topics in filter than log", LogFilter{Topics: [][]common.Hash{nil, nil}}, filterAddrA, []common.Hash{filterTopic1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.filter.Matches(tt.address, tt.topics))
+		})
+	}
+}
+
+func TestWSSubscriptionFilters(t *testing.T) {
+	f := newWSSubscriptionFilters()
+
+	logsSub := &RPCReq{JSONRPC: JSONRPCVersion, Method: "eth_subscribe", ID: []byte("1"),
+		Params: json.RawMessage(`["logs",{"address":"0x000000000000000000000000000000000000aaaa"}]`)}
+	headsSub := &RPCReq{JSONRPC: JSONRPCVersion, Method: "eth_subscribe", ID: []byte("2"),
+		Params: json.RawMessage(`["newHeads"]`)}
+	require.NoError(t, f.TrackRequest(logsSub))
+	require.NoError(t, f.TrackRequest(headsSub))
+
+	f.TrackResponse(NewRPCRes([]byte("1"), "0xlogs"))
+	f.TrackResponse(NewRPCRes([]byte("2"), "0xheads"))
+
+	matching := []byte(`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xlogs","result":{"address":"0x000000000000000000000000000000000000aaaa","topics":[]}}}`)
+	nonMatching := []byte(`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xlogs","result":{"address":"0x000000000000000000000000000000000000bbbb","topics":[]}}}`)
+	head := []byte(`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xheads","result":{"number":"0x1"}}}`)
+
+	require.True(t, f.ShouldForward(matching))
+	require.False(t, f.ShouldForward(nonMatching))
+	require.True(t, f.ShouldForward(head))
+	require.True(t, f.ShouldForward([]byte(`{"jsonrpc":"2.0","result":"0x1","id":3}`)))
+
+	unsub := &RPCReq{JSONRPC: JSONRPCVersion, Method: "eth_unsubscribe", ID: []byte("3"), Params: json.RawMessage(`["0xlogs"]`)}
+	require.NoError(t, f.TrackRequest(unsub))
+	require.True(t, f.ShouldForward(nonMatching))
+}